@@ -5,14 +5,45 @@ dotenv.config({ path: path.resolve(process.cwd(), '.env') });
 
 type EscrowMode = 'noncustodial' | 'custodial';
 
+const SCAN_PROVIDERS = ['none', 'clamav'] as const;
+const SCAN_FAIL_MODES = ['closed', 'open'] as const;
+export type ScanFailMode = (typeof SCAN_FAIL_MODES)[number];
+
+// Fails at startup rather than on first use when an enum-like setting is misspelled.
+function oneOf<T extends string>(name: string, val: string, allowed: readonly T[]): T {
+  if (!(allowed as readonly string[]).includes(val)) {
+    throw new Error(`Invalid ${name}='${val}'; expected one of: ${allowed.join(', ')}`);
+  }
+  return val as T;
+}
+
+// Same for rates: Number() would otherwise let NaN or -1 through silently.
+function fraction(name: string, val: string): number {
+  const n = Number(val);
+  if (val.trim() === '' || !(n >= 0 && n <= 1)) {
+    throw new Error(`Invalid ${name}='${val}'; expected a number between 0 and 1`);
+  }
+  return n;
+}
+
 function toBool(val: any, def = false): boolean {
   if (val === undefined) return def;
   return ['1', 'true', 'yes', 'on'].includes(String(val).toLowerCase());
 }
 
+const NODE_ENV = process.env.NODE_ENV ?? 'development';
+
 export const env = {
-  NODE_ENV: process.env.NODE_ENV ?? 'development',
+  NODE_ENV,
   PORT: Number(process.env.PORT ?? 3001),
+  // Number of reverse proxies in front of the app (Render, a load balancer); lets req.ip see the real client
+  TRUST_PROXY: Number(process.env.TRUST_PROXY ?? 0),
+  // Only worth waiting for a load balancer in production; locally Ctrl-C should stop the server straight away
+  SHUTDOWN_DELAY_MS: Number(process.env.SHUTDOWN_DELAY_MS ?? (NODE_ENV === 'production' ? 5000 : 0)),
+  SHUTDOWN_TIMEOUT_MS: Number(process.env.SHUTDOWN_TIMEOUT_MS ?? 15000),
+  READINESS_TIMEOUT_MS: Number(process.env.READINESS_TIMEOUT_MS ?? 2000),
+  // Fraction of 2xx/3xx requests written to the access log; 4xx/5xx are always logged
+  ACCESS_LOG_SAMPLE_RATE: fraction('ACCESS_LOG_SAMPLE_RATE', process.env.ACCESS_LOG_SAMPLE_RATE ?? '1'),
   RPC_URL: process.env.RPC_URL ?? 'https://testnet.hashio.io/api',
   CHAIN_ID: Number(process.env.CHAIN_ID ?? 296),
   NETWORK: process.env.NETWORK ?? 'hederaTestnet',
@@ -21,7 +52,29 @@ export const env = {
   CLERK_JWKS_URL: process.env.CLERK_JWKS_URL ?? '',
   SERVER_PRIVATE_KEY: process.env.SERVER_PRIVATE_KEY ?? '',
   ENABLE_WORKER: toBool(process.env.ENABLE_WORKER ?? 'false'),
+  // Webhook delivery: per-attempt timeout, attempts before a delivery is marked failed, and queue poll interval
+  WEBHOOK_TIMEOUT_MS: Number(process.env.WEBHOOK_TIMEOUT_MS ?? 10000),
+  WEBHOOK_MAX_ATTEMPTS: Number(process.env.WEBHOOK_MAX_ATTEMPTS ?? 8),
+  WEBHOOK_POLL_MS: Number(process.env.WEBHOOK_POLL_MS ?? 5000),
+  // Per-identity rate limits (requests per minute) for each router
+  RATE_LIMIT_ESCROW_PER_MIN: Number(process.env.RATE_LIMIT_ESCROW_PER_MIN ?? 10),
+  RATE_LIMIT_UPLOADS_PER_MIN: Number(process.env.RATE_LIMIT_UPLOADS_PER_MIN ?? 20),
+  RATE_LIMIT_READS_PER_MIN: Number(process.env.RATE_LIMIT_READS_PER_MIN ?? 120),
+  // Limits on transactions signed with SERVER_PRIVATE_KEY (custodial createEscrow, VC issuance)
+  SIGNER_MAX_FEE_PER_GAS_GWEI: process.env.SIGNER_MAX_FEE_PER_GAS_GWEI ?? '2000',
+  SIGNER_GAS_LIMIT: Number(process.env.SIGNER_GAS_LIMIT ?? 500000),
+  SIGNER_SPEND_BUDGET_HBAR: process.env.SIGNER_SPEND_BUDGET_HBAR ?? '1000',
+  SIGNER_SPEND_PERIOD_MS: Number(process.env.SIGNER_SPEND_PERIOD_MS ?? 24 * 60 * 60 * 1000),
   STORAGE_PROVIDER: process.env.STORAGE_PROVIDER ?? 'local',
+  MAX_UPLOAD_BYTES: Number(process.env.MAX_UPLOAD_BYTES ?? 10 * 1024 * 1024),
+  // Upload malware scanning: 'none' or 'clamav'
+  SCAN_PROVIDER: oneOf('SCAN_PROVIDER', process.env.SCAN_PROVIDER ?? 'none', SCAN_PROVIDERS),
+  SCAN_FAIL_MODE: oneOf('SCAN_FAIL_MODE', process.env.SCAN_FAIL_MODE ?? 'closed', SCAN_FAIL_MODES),
+  SCAN_TIMEOUT_MS: Number(process.env.SCAN_TIMEOUT_MS ?? 30000),
+  CLAMAV_HOST: process.env.CLAMAV_HOST ?? '127.0.0.1',
+  CLAMAV_PORT: Number(process.env.CLAMAV_PORT ?? 3310),
+  // Base URI for RFC 7807 problem types returned to clients sending Accept: application/problem+json
+  PROBLEM_BASE_URI: process.env.PROBLEM_BASE_URI ?? 'https://verza.io/problems',
   CONTRACTS_CONFIG_PATH: process.env.CONTRACTS_CONFIG_PATH ?? path.join('..','contracts','contract-config.json'),
   DEFAULT_VERIFIER_ADDRESS: process.env.DEFAULT_VERIFIER_ADDRESS ?? '',
   // Optional contract address overrides
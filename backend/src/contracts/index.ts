@@ -1,4 +1,5 @@
 import { Contract, JsonRpcProvider, Wallet, Interface, getAddress, formatEther } from 'ethers';
+import type { Logger } from 'pino';
 import fs from 'fs';
 import path from 'path';
 import { env, contractsConfigPath } from '../config/env';
@@ -43,7 +44,8 @@ function readAddresses() {
   };
 }
 
-export function getContracts(): Contracts {
+// Routes pass req.log so the load is logged with the request's reqId.
+export function getContracts(log: Logger = logger): Contracts {
   const provider = new JsonRpcProvider(env.RPC_URL, env.CHAIN_ID, { batchMaxCount: 1 });
   const signer = env.SERVER_PRIVATE_KEY ? new Wallet(env.SERVER_PRIVATE_KEY, provider) : undefined;
 
@@ -61,7 +63,7 @@ export function getContracts(): Contracts {
   const registry = new Contract(addresses.registry, registryArtifact.abi, signer ?? provider);
   const marketplace = new Contract(addresses.marketplace, marketplaceArtifact.abi, signer ?? provider);
 
-  logger.info({ network: env.NETWORK, chainId: env.CHAIN_ID, escrow: addresses.escrow, registry: addresses.registry, marketplace: addresses.marketplace }, 'Loaded contracts');
+  log.info({ network: env.NETWORK, chainId: env.CHAIN_ID, escrow: addresses.escrow, registry: addresses.registry, marketplace: addresses.marketplace }, 'Loaded contracts');
 
   return {
     provider,
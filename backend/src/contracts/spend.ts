@@ -0,0 +1,90 @@
+import { parseEther, parseUnits } from 'ethers';
+import { env } from '../config/env';
+
+type FeeSource = { getFeeData(): Promise<{ gasPrice: bigint | null; maxFeePerGas: bigint | null; maxPriorityFeePerGas: bigint | null }> };
+
+export type SpendLimits = {
+  /** Highest fee per gas the server wallet will offer; sends are refused while the network asks for more. */
+  maxFeePerGas: bigint;
+  /** Gas limit set on every server-signed transaction. */
+  gasLimit: bigint;
+  /** Total the server wallet may spend per period: value sent plus the worst-case gas fee. */
+  budget: bigint;
+  periodMs: number;
+};
+
+export type SpendOverrides = { value: bigint; gasLimit: bigint } & ({ maxFeePerGas: bigint; maxPriorityFeePerGas: bigint } | { gasPrice: bigint });
+
+export class SpendRefusedError extends Error {
+  constructor(readonly reason: 'fee_cap' | 'budget', message: string) {
+    super(message);
+    this.name = 'SpendRefusedError';
+  }
+}
+
+/**
+ * Guards every transaction the server wallet signs. authorize() caps gas and reserves the worst-case cost
+ * (value + gasLimit * fee) against a fixed-window budget; settle() gives back what the receipt shows went unused.
+ * The budget is per process, so each replica gets its own.
+ */
+export class SpendGuard {
+  private spent = 0n;
+  private windowStart: number;
+
+  constructor(private limits: SpendLimits, private now: () => number = Date.now) {
+    this.windowStart = now();
+  }
+
+  remaining(): bigint {
+    this.roll();
+    return this.limits.budget - this.spent;
+  }
+
+  async authorize(provider: FeeSource, value = 0n) {
+    const { maxFeePerGas: cap, gasLimit } = this.limits;
+    const fees = await provider.getFeeData();
+    const eip1559 = fees.maxFeePerGas !== null;
+    const fee = (eip1559 ? fees.maxFeePerGas : fees.gasPrice) ?? cap;
+    if (fee > cap) {
+      throw new SpendRefusedError('fee_cap', `Network fee ${fee} wei/gas is above the cap of ${cap}`);
+    }
+
+    this.roll();
+    const reserved = value + gasLimit * fee;
+    if (this.spent + reserved > this.limits.budget) {
+      throw new SpendRefusedError('budget', `Spend of ${reserved} wei would exceed the remaining budget of ${this.limits.budget - this.spent}`);
+    }
+    this.spent += reserved;
+    const window = this.windowStart;
+
+    const overrides: SpendOverrides = eip1559
+      ? { value, gasLimit, maxFeePerGas: fee, maxPriorityFeePerGas: min(fees.maxPriorityFeePerGas ?? 0n, fee) }
+      : { value, gasLimit, gasPrice: fee };
+    return {
+      overrides,
+      /** Releases the unused part of the reservation once the receipt shows the actual fee. */
+      settle: (receipt: { fee?: bigint } | null | undefined) => {
+        if (receipt?.fee === undefined || window !== this.windowStart) return;
+        this.spent -= reserved - (value + receipt.fee);
+      },
+    };
+  }
+
+  private roll() {
+    const now = this.now();
+    if (now - this.windowStart < this.limits.periodMs) return;
+    this.windowStart = now;
+    this.spent = 0n;
+  }
+}
+
+function min(a: bigint, b: bigint): bigint {
+  return a < b ? a : b;
+}
+
+export const signerSpend = new SpendGuard({
+  maxFeePerGas: parseUnits(env.SIGNER_MAX_FEE_PER_GAS_GWEI, 'gwei'),
+  gasLimit: BigInt(env.SIGNER_GAS_LIMIT),
+  budget: parseEther(env.SIGNER_SPEND_BUDGET_HBAR),
+  periodMs: env.SIGNER_SPEND_PERIOD_MS,
+});
@@ -1,3 +1,8 @@
 import { PrismaClient } from '@prisma/client';
 
-export const prisma = new PrismaClient({});
\ No newline at end of file
+export const prisma = new PrismaClient({});
+
+// P2002: a write hit a unique constraint (e.g. the row already exists).
+export function isUniqueViolation(e: unknown): boolean {
+  return (e as { code?: unknown } | null)?.code === 'P2002';
+}
@@ -3,37 +3,87 @@ import cors from 'cors';
 import morgan from 'morgan';
 import { env } from './config/env';
 import { logger } from './logger';
+import { codeForThrown, sendError } from './utils/errors';
+import { sampleSuccessfulRequests } from './utils/logSampling';
+import { prisma } from './db/client';
+import { isShuttingDown, markShuttingDown } from './lifecycle';
+import { REQUEST_ID_HEADER, requestIdMiddleware } from './middleware/requestId';
 import healthRouter from './routes/health';
 import verifiersRouter from './routes/verifiers';
 import escrowRouter from './routes/escrow';
 import resultsRouter from './routes/results';
 import uploadsRouter from './routes/uploads';
+import webhooksRouter from './routes/webhooks';
 
 const app = express();
-app.use(cors());
+app.set('trust proxy', env.TRUST_PROXY);
+app.use(requestIdMiddleware);
+app.use(cors({ exposedHeaders: [REQUEST_ID_HEADER, 'Retry-After', 'X-RateLimit-Limit', 'X-RateLimit-Remaining'] }));
 app.use(express.json({ limit: '2mb' }));
 app.use(express.urlencoded({ extended: true }));
-app.use(morgan('dev'));
+morgan.token<express.Request, express.Response>('id', (req) => req.id);
+app.use(morgan('[:id] :method :url :status :response-time ms - :res[content-length]', {
+  skip: sampleSuccessfulRequests(env.ACCESS_LOG_SAMPLE_RATE),
+}));
 
 app.use('/health', healthRouter);
 app.use('/verifiers', verifiersRouter);
 app.use('/escrow', escrowRouter);
 app.use('/verification', resultsRouter);
 app.use('/uploads', uploadsRouter);
+app.use('/webhooks', webhooksRouter);
 
-app.use((err: any, _req: express.Request, res: express.Response, _next: express.NextFunction) => {
-  logger.error({ err }, 'Unhandled error');
-  res.status(500).json({ error: 'Internal Server Error' });
+app.use((err: any, req: express.Request, res: express.Response, _next: express.NextFunction) => {
+  const code = codeForThrown(err);
+  if (code === 'INTERNAL_ERROR') req.log.error({ err }, 'Unhandled error');
+  else req.log.warn({ err: err?.message, code }, 'Request rejected');
+  sendError(req, res, code);
 });
 
 const port = env.PORT;
-app.listen(port, () => {
+const server = app.listen(port, () => {
   logger.info({ port }, 'Backend API listening');
 });
 
+function shutdown(signal: string) {
+  if (isShuttingDown()) {
+    logger.warn({ signal }, 'Second shutdown signal; exiting immediately');
+    process.exit(1);
+  }
+  // Fail readiness first and keep serving for SHUTDOWN_DELAY_MS so load balancers stop routing here before we close.
+  markShuttingDown();
+  logger.info({ signal, delayMs: env.SHUTDOWN_DELAY_MS }, 'Shutting down: readiness failing, waiting before drain');
+
+  const force = setTimeout(() => {
+    logger.warn({ timeoutMs: env.SHUTDOWN_TIMEOUT_MS }, 'Shutdown timed out; forcing exit');
+    process.exit(1);
+  }, env.SHUTDOWN_DELAY_MS + env.SHUTDOWN_TIMEOUT_MS);
+  force.unref();
+
+  setTimeout(() => {
+    logger.info('Draining in-flight requests');
+    server.close(async (err) => {
+      await prisma.$disconnect().catch(() => {});
+      if (err) {
+        logger.error({ err }, 'Error while closing server');
+        process.exit(1);
+      }
+      logger.info('Shutdown complete');
+      process.exit(0);
+    });
+    server.closeIdleConnections();
+  }, env.SHUTDOWN_DELAY_MS);
+}
+
+process.on('SIGTERM', () => shutdown('SIGTERM'));
+process.on('SIGINT', () => shutdown('SIGINT'));
+
 // Optional: start worker
 if (env.ENABLE_WORKER) {
   import('./workers/chainWorker')
     .then(({ startChainWorker }) => startChainWorker())
     .catch((e) => logger.error({ e }, 'Failed to start worker'));
+  import('./webhooks')
+    .then(({ startWebhookWorker }) => startWebhookWorker())
+    .catch((e) => logger.error({ e }, 'Failed to start webhook worker'));
 }
\ No newline at end of file
@@ -0,0 +1,9 @@
+let shuttingDown = false;
+
+export function markShuttingDown() {
+  shuttingDown = true;
+}
+
+export function isShuttingDown(): boolean {
+  return shuttingDown;
+}
@@ -2,6 +2,7 @@ import { NextFunction, Request, Response } from 'express';
 import { env } from '../config/env';
 import { jwtVerify, createRemoteJWKSet, JWTPayload } from 'jose';
 import { URL } from 'url';
+import { sendError } from '../utils/errors';
 
 export interface AuthUser {
   id: string;
@@ -28,16 +29,16 @@ export async function authMiddleware(req: Request, res: Response, next: NextFunc
   try {
     const auth = req.headers.authorization || '';
     const token = auth.startsWith('Bearer ') ? auth.substring(7) : '';
-    if (!token) return res.status(401).json({ error: 'Missing Bearer token' });
+    if (!token) return sendError(req, res, 'MISSING_TOKEN');
 
-    if (!jwks) return res.status(500).json({ error: 'Auth not configured' });
+    if (!jwks) return sendError(req, res, 'AUTH_NOT_CONFIGURED');
 
     const { payload } = await jwtVerify(token, jwks);
     const user = mapClerkPayload(payload);
     req.user = user;
     next();
   } catch (e) {
-    return res.status(401).json({ error: 'Invalid token' });
+    return sendError(req, res, 'INVALID_TOKEN');
   }
 }
 
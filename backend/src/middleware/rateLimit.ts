@@ -0,0 +1,72 @@
+import { NextFunction, Request, Response } from 'express';
+import { sendError } from '../utils/errors';
+
+export type Bucket = { capacity: number; refillPerSec: number };
+export type TakeResult = { allowed: boolean; remaining: number; retryAfterMs: number };
+
+/** Token-bucket storage; take() must refill and consume atomically so a shared store (e.g. Redis) can slot in. */
+export interface RateLimitStore {
+  take(key: string, bucket: Bucket, now: number): Promise<TakeResult>;
+}
+
+/** Per-process buckets. Least recently used keys are evicted beyond `maxKeys`. */
+export class MemoryRateLimitStore implements RateLimitStore {
+  private buckets = new Map<string, { tokens: number; updatedAt: number }>();
+
+  constructor(private maxKeys = 10_000) {}
+
+  async take(key: string, { capacity, refillPerSec }: Bucket, now: number): Promise<TakeResult> {
+    const prev = this.buckets.get(key);
+    const elapsed = prev ? Math.max(0, now - prev.updatedAt) / 1000 : 0;
+    const state = { tokens: prev ? Math.min(capacity, prev.tokens + elapsed * refillPerSec) : capacity, updatedAt: now };
+
+    this.buckets.delete(key); // re-insert to keep Map order = recency
+    this.buckets.set(key, state);
+    if (this.buckets.size > this.maxKeys) this.buckets.delete(this.buckets.keys().next().value!);
+
+    if (state.tokens >= 1) {
+      state.tokens -= 1;
+      return { allowed: true, remaining: Math.floor(state.tokens), retryAfterMs: 0 };
+    }
+    return { allowed: false, remaining: 0, retryAfterMs: Math.ceil(((1 - state.tokens) / refillPerSec) * 1000) };
+  }
+}
+
+const defaultStore = new MemoryRateLimitStore();
+
+export type RateLimitOptions = {
+  /** Sustained requests per minute per identity. */
+  perMinute: number;
+  /** Requests allowed in a burst; defaults to perMinute. */
+  burst?: number;
+  store?: RateLimitStore;
+  now?: () => number;
+};
+
+/**
+ * Token-bucket limiter keyed on the authenticated user, falling back to the client IP. `name` separates the buckets
+ * of each router, so every router can have its own limits. Mount it after authMiddleware. If the store fails the
+ * request is allowed and a warning logged, so a cache outage doesn't take the API down.
+ */
+export function rateLimit(name: string, { perMinute, burst = perMinute, store = defaultStore, now = Date.now }: RateLimitOptions) {
+  const bucket: Bucket = { capacity: burst, refillPerSec: perMinute / 60 };
+
+  return async (req: Request, res: Response, next: NextFunction) => {
+    const identity = req.user ? `user:${req.user.id}` : `ip:${req.ip}`;
+    let result: TakeResult;
+    try {
+      result = await store.take(`${name}:${identity}`, bucket, now());
+    } catch (e: any) {
+      req.log.warn({ limiter: name, err: e?.message }, 'Rate limit store unavailable; allowing request');
+      return next();
+    }
+
+    res.setHeader('X-RateLimit-Limit', bucket.capacity);
+    res.setHeader('X-RateLimit-Remaining', result.remaining);
+    if (!result.allowed) {
+      res.setHeader('Retry-After', Math.ceil(result.retryAfterMs / 1000));
+      return sendError(req, res, 'RATE_LIMITED');
+    }
+    next();
+  };
+}
@@ -0,0 +1,28 @@
+import { NextFunction, Request, Response } from 'express';
+import { randomUUID } from 'crypto';
+import type { Logger } from 'pino';
+import { logger } from '../logger';
+
+export const REQUEST_ID_HEADER = 'X-Request-ID';
+
+declare global {
+  namespace Express {
+    interface Request {
+      id: string;
+      log: Logger;
+    }
+  }
+}
+
+// Accept caller-supplied ids only if they look like ids, so arbitrary header content never reaches the logs.
+const validId = /^[A-Za-z0-9._:-]{1,128}$/;
+
+export function requestIdMiddleware(req: Request, res: Response, next: NextFunction) {
+  const incoming = req.get(REQUEST_ID_HEADER);
+  const id = incoming && validId.test(incoming) ? incoming : randomUUID();
+  req.id = id;
+  // Bound as `reqId`: `requestId` already means the on-chain escrow id in logs (see workers/chainWorker).
+  req.log = logger.child({ reqId: id });
+  res.setHeader(REQUEST_ID_HEADER, id);
+  next();
+}
@@ -1,14 +1,24 @@
-import { Router } from 'express';
+import { Request, Response, Router } from 'express';
 import { z } from 'zod';
+import type { Logger } from 'pino';
 import { authMiddleware } from '../middleware/auth';
-import { prisma } from '../db/client';
-import { getContracts } from '../contracts';
+import { rateLimit } from '../middleware/rateLimit';
+import { PrismaClient } from '@prisma/client';
+import { isUniqueViolation, prisma } from '../db/client';
+import { Contracts, getContracts } from '../contracts';
+import { signerSpend, SpendGuard, SpendRefusedError } from '../contracts/spend';
 import { genRequestId } from '../utils/ids';
 import { env } from '../config/env';
-import { AddressLike, Contract, Interface, JsonRpcProvider, parseEther, zeroPadValue } from 'ethers';
+import { sendError } from '../utils/errors';
+import { FieldSchema, parseFieldsParam, pickFields } from '../utils/fields';
+import { AddressLike, Contract, Interface, JsonRpcProvider, parseEther, randomBytes, toBigInt, zeroPadValue } from 'ethers';
 
 const router = Router();
 
+// Custodial initiates spend server funds, so this is the strictest limit.
+const initiateLimit = rateLimit('escrow', { perMinute: env.RATE_LIMIT_ESCROW_PER_MIN });
+const readLimit = rateLimit('escrow-read', { perMinute: env.RATE_LIMIT_READS_PER_MIN });
+
 const initiateSchema = z.object({
   verifier_id: z.string(),
   currency: z.string().default('HBAR'),
@@ -18,45 +28,63 @@ const initiateSchema = z.object({
 
 type InitiateBody = z.infer<typeof initiateSchema>;
 
-router.post('/initiate', authMiddleware, async (req, res) => {
+const escrowStatusFields: FieldSchema = {
+  escrowId: true,
+  status: true,
+  steps: { key: true, status: true },
+};
+
+// Chain, database and nonce source for POST /initiate; tests swap these for the in-memory fakes in test/fakes.
+export type EscrowDeps = {
+  db: Pick<PrismaClient, 'user' | 'verifier' | 'escrow'>;
+  contracts: (log?: Logger) => Contracts;
+  /** Nonce mixed into custodial escrow ids. */
+  nonce: () => bigint;
+  /** Fee cap and budget for the custodial createEscrow sent from the server wallet. */
+  spend: SpendGuard;
+};
+
+const defaultDeps: EscrowDeps = { db: prisma, contracts: getContracts, nonce: () => toBigInt(randomBytes(32)), spend: signerSpend };
+
+const initiate = ({ db, contracts, nonce, spend }: EscrowDeps) => async (req: Request, res: Response) => {
   const parse = initiateSchema.safeParse(req.body);
-  if (!parse.success) return res.status(400).json({ error: parse.error.flatten() });
+  if (!parse.success) return sendError(req, res, 'VALIDATION_FAILED', parse.error.flatten());
   const body = parse.data as InitiateBody;
 
   // Ensure user exists
-  const user = await prisma.user.upsert({
+  const user = await db.user.upsert({
     where: { clerkUserId: req.user!.id },
     update: {},
     create: { clerkUserId: req.user!.id, walletAddress: body.wallet_address },
   });
   if (body.wallet_address && user.walletAddress !== body.wallet_address) {
-    await prisma.user.update({ where: { id: user.id }, data: { walletAddress: body.wallet_address } });
+    await db.user.update({ where: { id: user.id }, data: { walletAddress: body.wallet_address } });
   }
 
   // Resolve verifier by ID or onchain address
-  let verifier = await prisma.verifier.findUnique({ where: { id: body.verifier_id } });
+  let verifier = await db.verifier.findUnique({ where: { id: body.verifier_id } });
   if (!verifier && body.verifier_id.startsWith('0x') && body.verifier_id.length === 42) {
-    verifier = await prisma.verifier.findUnique({ where: { onchainAddress: body.verifier_id } });
+    verifier = await db.verifier.findUnique({ where: { onchainAddress: body.verifier_id } });
     if (!verifier) {
       // Create placeholder verifier record
-      verifier = await prisma.verifier.create({ data: { name: 'Verifier', onchainAddress: body.verifier_id, currency: body.currency } });
+      verifier = await db.verifier.create({ data: { name: 'Verifier', onchainAddress: body.verifier_id, currency: body.currency } });
     }
   }
-  if (!verifier) return res.status(404).json({ error: 'Verifier not found' });
+  if (!verifier) return sendError(req, res, 'VERIFIER_NOT_FOUND');
 
-  const { provider, marketplace, escrow, iface, addresses } = getContracts();
+  const { provider, marketplace, escrow, iface, addresses } = contracts(req.log);
 
   // Calculate on-chain verification fee and prepare transaction
   let verificationFee: bigint;
   try {
     verificationFee = await marketplace.calculateVerificationFee(verifier.onchainAddress);
   } catch (e) {
-    return res.status(400).json({ error: 'Failed to calculate verification fee' });
+    return sendError(req, res, 'FEE_CALCULATION_FAILED');
   }
 
   const walletAddress = user.walletAddress || body.wallet_address;
   if (env.ESCROW_MODE === 'noncustodial') {
-    if (!walletAddress) return res.status(400).json({ error: 'Missing user wallet_address for non-custodial flow' });
+    if (!walletAddress) return sendError(req, res, 'WALLET_ADDRESS_REQUIRED');
 
     const now = BigInt(Math.floor(Date.now() / 1000));
     const nonce = BigInt(Date.now());
@@ -79,7 +107,7 @@ router.post('/initiate', authMiddleware, async (req, res) => {
     }
 
     // Persist escrow record
-    await prisma.escrow.create({
+    await db.escrow.create({
       data: {
         id: requestId,
         requestId,
@@ -106,40 +134,61 @@ router.post('/initiate', authMiddleware, async (req, res) => {
     // Custodial: server submits the tx using signer
     const signer = (escrow.runner as any);
     if (!signer || !('provider' in signer)) {
-      return res.status(500).json({ error: 'Server signer not configured' });
+      return sendError(req, res, 'SIGNER_NOT_CONFIGURED');
     }
 
+    // Every custodial escrow is created by the same signer, so the nonce must be random: two users picking the same
+    // verifier in the same millisecond would otherwise get the same id.
     const now = BigInt(Math.floor(Date.now() / 1000));
-    const nonce = BigInt(Date.now());
-    const requestId = genRequestId(signer.address, verifier.onchainAddress, nonce, now);
+    const requestId = genRequestId(signer.address, verifier.onchainAddress, nonce(), now);
 
+    let txHash: string | undefined;
     try {
-      const tx = await escrow.createEscrow(requestId, verifier.onchainAddress, { value: verificationFee });
+      const { overrides, settle } = await spend.authorize(provider, verificationFee);
+      const tx = await escrow.createEscrow(requestId, verifier.onchainAddress, overrides);
       const receipt = await tx.wait();
+      settle(receipt);
+      txHash = receipt?.hash;
+    } catch (e: any) {
+      if (e instanceof SpendRefusedError) {
+        req.log.error({ alert: 'signer_spend_refused', reason: e.reason, err: e.message, escrowId: requestId }, 'Refused custodial createEscrow');
+        return sendError(req, res, e.reason === 'budget' ? 'SPEND_BUDGET_EXCEEDED' : 'NETWORK_FEE_TOO_HIGH');
+      }
+      return sendError(req, res, 'ESCROW_SUBMISSION_FAILED', e?.message);
+    }
 
-      await prisma.escrow.create({
-        data: {
-          id: requestId,
-          requestId,
-          userId: user.id,
-          verifierId: verifier.id,
-          amount: verificationFee,
-          currency: body.currency,
-          txHash: receipt?.hash,
-          status: 'submitted',
-        }
+    const owner = { userId: user.id, verifierId: verifier.id, currency: body.currency, txHash };
+    try {
+      await db.escrow.create({ data: { id: requestId, requestId, amount: verificationFee, status: 'submitted', ...owner } });
+    } catch (e) {
+      if (!isUniqueViolation(e)) throw e;
+      // The chain worker may have stored this escrow first from EscrowCreated, owned by a placeholder user keyed on the
+      // signer address. Claim only that row; an escrow owned by anyone else is a genuine id collision.
+      const { count } = await db.escrow.updateMany({
+        where: { id: requestId, user: { clerkUserId: signer.address.toLowerCase() } },
+        data: owner,
       });
-
-      return res.json({ escrow_id: requestId, status: 'submitted', tx_hash: receipt?.hash });
-    } catch (e: any) {
-      return res.status(500).json({ error: 'Escrow submission failed', details: e?.message });
+      if (count === 0) {
+        req.log.error({ escrowId: requestId, txHash }, 'Custodial escrow id already owned by another user');
+        return sendError(req, res, 'ESCROW_CONFLICT');
+      }
     }
+
+    return res.json({ escrow_id: requestId, status: 'submitted', tx_hash: txHash });
   }
-});
+};
+
+// Builds the POST /initiate handler; any dependency left out uses the real database, contracts or nonce source.
+export const initiateEscrow = (deps: Partial<EscrowDeps> = {}) => initiate({ ...defaultDeps, ...deps });
+
+router.post('/initiate', authMiddleware, initiateLimit, initiateEscrow());
+
+router.get('/status/:escrowId', authMiddleware, readLimit, async (req, res) => {
+  const { fields, unknown } = parseFieldsParam(req.query.fields, escrowStatusFields);
+  if (unknown.length) return sendError(req, res, 'UNKNOWN_FIELDS', { fields: unknown });
 
-router.get('/status/:escrowId', authMiddleware, async (req, res) => {
   const escrow = await prisma.escrow.findUnique({ where: { id: req.params.escrowId }, include: { verification: true, credential: true } });
-  if (!escrow) return res.status(404).json({ error: 'Escrow not found' });
+  if (!escrow) return sendError(req, res, 'ESCROW_NOT_FOUND');
 
   const steps = [
     { key: 'created', status: ['submitted','in_progress','completed','refunded','cancelled'].includes(escrow.status) ? 'done' : 'pending' },
@@ -149,7 +198,7 @@ router.get('/status/:escrowId', authMiddleware, async (req, res) => {
     { key: 'settlement', status: ['completed','refunded'].includes(escrow.status) ? 'done' : 'pending' },
   ];
 
-  res.json({ escrowId: escrow.id, status: escrow.status, steps });
+  res.json(pickFields({ escrowId: escrow.id, status: escrow.status, steps }, fields));
 });
 
 export default router;
\ No newline at end of file
@@ -1,8 +1,55 @@
-import { Router } from 'express';
+import { Request, Router } from 'express';
+import { JsonRpcProvider } from 'ethers';
+import { env } from '../config/env';
+import { prisma } from '../db/client';
+import { isShuttingDown } from '../lifecycle';
 
 const router = Router();
+
+// Liveness: must stay cheap and never touch dependencies.
 router.get('/', (_req, res) => {
   res.json({ ok: true, service: 'verza-backend', ts: new Date().toISOString() });
 });
 
-export default router;
\ No newline at end of file
+let rpcProvider: JsonRpcProvider | null = null;
+
+function provider(): JsonRpcProvider {
+  if (!rpcProvider) rpcProvider = new JsonRpcProvider(env.RPC_URL, env.CHAIN_ID, { staticNetwork: true });
+  return rpcProvider;
+}
+
+function withTimeout<T>(p: Promise<T>, ms: number): Promise<T> {
+  return new Promise((resolve, reject) => {
+    const timer = setTimeout(() => reject(new Error(`timed out after ${ms}ms`)), ms);
+    timer.unref();
+    p.then(resolve, reject).finally(() => clearTimeout(timer));
+  });
+}
+
+// The endpoint is unauthenticated, so the failure detail only goes to the log.
+async function check(req: Request, name: string, fn: () => Promise<unknown>): Promise<'ok' | 'failed'> {
+  try {
+    await withTimeout(fn(), env.READINESS_TIMEOUT_MS);
+    return 'ok';
+  } catch (e: any) {
+    req.log.warn({ check: name, err: e?.message ?? String(e) }, 'Readiness check failed');
+    return 'failed';
+  }
+}
+
+// Readiness: reports 503 while draining or when the database or chain RPC is unreachable.
+router.get('/ready', async (req, res) => {
+  if (isShuttingDown()) {
+    return res.status(503).json({ ready: false, reason: 'shutting_down' });
+  }
+
+  const [database, rpc] = await Promise.all([
+    check(req, 'database', () => prisma.$queryRaw`SELECT 1`),
+    check(req, 'rpc', () => provider().getBlockNumber()),
+  ]);
+  const ready = database === 'ok' && rpc === 'ok';
+
+  res.status(ready ? 200 : 503).json({ ready, checks: { database, rpc } });
+});
+
+export default router;
@@ -1,21 +1,25 @@
 import { Router } from 'express';
 import { authMiddleware } from '../middleware/auth';
+import { rateLimit } from '../middleware/rateLimit';
 import { prisma } from '../db/client';
 import { env } from '../config/env';
 import { getContracts } from '../contracts';
+import { sendError } from '../utils/errors';
 
 const router = Router();
 
-router.get('/results/:escrowId', authMiddleware, async (req, res) => {
+const readLimit = rateLimit('results', { perMinute: env.RATE_LIMIT_READS_PER_MIN });
+
+router.get('/results/:escrowId', authMiddleware, readLimit, async (req, res) => {
   const escrow = await prisma.escrow.findUnique({ where: { id: req.params.escrowId }, include: { credential: true, user: true, verifier: true } });
-  if (!escrow) return res.status(404).json({ error: 'Escrow not found' });
+  if (!escrow) return sendError(req, res, 'ESCROW_NOT_FOUND');
   const verified = !!escrow.credential;
 
   const credential = verified ? {
     type: escrow.credential!.type,
     issuer: { name: 'Verza', did: escrow.user.did ?? null },
     subject: { did: escrow.user.did ?? null },
-    chain: { chainId: env.CHAIN_ID, registry: getContracts().addresses.registry, tokenId: escrow.credential!.tokenId.toString(), tokenURI: escrow.credential!.tokenUri },
+    chain: { chainId: env.CHAIN_ID, registry: getContracts(req.log).addresses.registry, tokenId: escrow.credential!.tokenId.toString(), tokenURI: escrow.credential!.tokenUri },
     issuedAt: escrow.credential!.issuedAt.toISOString(),
     attributes: {}
   } : undefined;
@@ -1,15 +1,24 @@
-import { Router } from 'express';
+import { NextFunction, Request, Response, Router } from 'express';
 import { authMiddleware } from '../middleware/auth';
+import { rateLimit } from '../middleware/rateLimit';
 import multer from 'multer';
 import fs from 'fs';
 import path from 'path';
 import { prisma } from '../db/client';
+import { env } from '../config/env';
+import { getScanner } from '../scanning';
+import { screenFiles } from '../scanning/screen';
+import { ALLOWED_UPLOAD_TYPES, detectContentType, SNIFF_BYTES } from '../utils/contentType';
+import { ErrorCode, sendError } from '../utils/errors';
 
 const router = Router();
 
-router.post('/presign', authMiddleware, async (req, res) => {
+// Applied before multer so rejected requests never write to disk or reach the scanner.
+const uploadLimit = rateLimit('uploads', { perMinute: env.RATE_LIMIT_UPLOADS_PER_MIN });
+
+router.post('/presign', authMiddleware, uploadLimit, async (req, res) => {
   const { escrowId } = req.body as { escrowId?: string };
-  if (!escrowId) return res.status(400).json({ error: 'escrowId required' });
+  if (!escrowId) return sendError(req, res, 'ESCROW_ID_REQUIRED');
   // For local dev, return direct upload endpoint
   res.json({
     docUploadUrl: `/uploads/verification/${escrowId}/documents`,
@@ -21,10 +30,20 @@ function ensureDir(dir: string) {
   if (!fs.existsSync(dir)) fs.mkdirSync(dir, { recursive: true });
 }
 
+const ESCROW_ID = /^0x[0-9a-fA-F]{64}$/;
+
+// escrowId comes from the URL (decoded, so `..%2F` arrives as `../`); refuse anything that would leave uploads/<area>.
+function uploadDir(area: 'staging' | 'verification' | 'quarantine', escrowId: string): string {
+  const base = path.resolve(process.cwd(), 'uploads', area);
+  const dir = path.resolve(base, escrowId);
+  if (path.dirname(dir) !== base) throw new Error(`Upload directory escapes uploads/${area}: ${escrowId}`);
+  return dir;
+}
+
+// Files land in staging and only move under uploads/verification once they have been sniffed and scanned.
 const storage = multer.diskStorage({
   destination: function (req, file, cb) {
-    const escrowId = req.params.escrowId;
-    const dir = path.resolve(process.cwd(), 'uploads', 'verification', escrowId);
+    const dir = uploadDir('staging', req.params.escrowId);
     ensureDir(dir);
     cb(null, dir);
   },
@@ -36,14 +55,105 @@ const storage = multer.diskStorage({
   }
 });
 
-const upload = multer({ storage });
+const upload = multer({ storage, limits: { fileSize: env.MAX_UPLOAD_BYTES } });
+
+const documentFields = upload.fields([{ name: 'document', maxCount: 5 }, { name: 'selfie', maxCount: 1 }]);
+
+const multerErrorCodes: Record<string, ErrorCode> = {
+  LIMIT_FILE_SIZE: 'FILE_TOO_LARGE',
+  LIMIT_FILE_COUNT: 'TOO_MANY_FILES',
+  LIMIT_PART_COUNT: 'TOO_MANY_FILES',
+  LIMIT_UNEXPECTED_FILE: 'UNEXPECTED_FILE_FIELD',
+  MISSING_FIELD_NAME: 'UNEXPECTED_FILE_FIELD',
+};
+
+// Maps a multer client error onto the error catalog; anything unlisted is reported as INVALID_MULTIPART.
+export function multerErrorResponse(err: multer.MulterError): { code: ErrorCode; details: Record<string, unknown> } {
+  const code = multerErrorCodes[err.code] ?? 'INVALID_MULTIPART';
+  const details = code === 'FILE_TOO_LARGE' ? { max_bytes: env.MAX_UPLOAD_BYTES } : { field: err.field, reason: err.code };
+  return { code, details };
+}
 
-router.post('/verification/:escrowId/documents', authMiddleware, upload.fields([{ name: 'document', maxCount: 5 }, { name: 'selfie', maxCount: 1 }]), async (req, res) => {
+// Wraps multer so client mistakes (oversized file, too many files, wrong field) are reported as 4xx
+// instead of falling through to the 500 handler. The escrow is checked first so nothing is written
+// to disk for a malformed or unknown escrowId.
+async function receiveDocuments(req: Request, res: Response, next: NextFunction) {
   const escrowId = req.params.escrowId;
+  if (!ESCROW_ID.test(escrowId)) return sendError(req, res, 'INVALID_ESCROW_ID');
   const escrow = await prisma.escrow.findUnique({ where: { id: escrowId } });
-  if (!escrow) return res.status(404).json({ error: 'Escrow not found' });
+  if (!escrow) return sendError(req, res, 'ESCROW_NOT_FOUND');
+
+  documentFields(req, res, (err: any) => {
+    if (err instanceof multer.MulterError) {
+      const { code, details } = multerErrorResponse(err);
+      return sendError(req, res, code, details);
+    }
+    if (err) return next(err);
+    next();
+  });
+}
+
+function sniffFile(filePath: string): string {
+  const fd = fs.openSync(filePath, 'r');
+  try {
+    const head = Buffer.alloc(SNIFF_BYTES);
+    const read = fs.readSync(fd, head, 0, SNIFF_BYTES, 0);
+    return detectContentType(head.subarray(0, read));
+  } finally {
+    fs.closeSync(fd);
+  }
+}
+
+function removeFiles(files: Express.Multer.File[]) {
+  for (const f of files) {
+    try { fs.unlinkSync(f.path); } catch {}
+  }
+}
+
+// Moves a staged file into another upload area and updates its path.
+function moveFile(area: 'verification' | 'quarantine', escrowId: string, file: Express.Multer.File): string {
+  const dir = uploadDir(area, escrowId);
+  ensureDir(dir);
+  const dest = path.join(dir, path.basename(file.path));
+  fs.renameSync(file.path, dest);
+  file.path = dest;
+  return dest;
+}
+
+router.post('/verification/:escrowId/documents', authMiddleware, uploadLimit, receiveDocuments, async (req, res) => {
+  const escrowId = req.params.escrowId;
+  const files = (req.files || {}) as { [field: string]: Express.Multer.File[] };
+  const allFiles = Object.values(files).flat();
+
+  // Reject anything that isn't an image or PDF by its actual bytes, not the client-supplied mimetype
+  for (const f of allFiles) {
+    const detected = sniffFile(f.path);
+    if (!ALLOWED_UPLOAD_TYPES.includes(detected)) {
+      removeFiles(allFiles);
+      return sendError(req, res, 'UNSUPPORTED_FILE_TYPE', { file: f.originalname, detected, allowed: ALLOWED_UPLOAD_TYPES });
+    }
+  }
+
+  const scanner = getScanner();
+  const screened = await screenFiles(allFiles, scanner, env.SCAN_FAIL_MODE);
+  if (!screened.ok) {
+    if (screened.reason === 'unavailable') {
+      req.log.error({ escrowId, file: screened.file.originalname, scanner: scanner.name, err: screened.err }, 'Upload scan failed; rejecting upload (fail-closed)');
+      removeFiles(allFiles);
+      return sendError(req, res, 'SCAN_UNAVAILABLE');
+    }
+    const infected = screened.file;
+    const quarantined = moveFile('quarantine', escrowId, infected);
+    removeFiles(allFiles.filter(other => other !== infected));
+    req.log.error({ escrowId, userId: req.user?.id, file: infected.originalname, signature: screened.signature, quarantined }, 'Malware detected in upload; file quarantined');
+    return sendError(req, res, 'MALWARE_DETECTED', { file: infected.originalname });
+  }
+  for (const { file, err } of screened.unscanned) {
+    req.log.warn({ escrowId, file: file.originalname, scanner: scanner.name, err }, 'Upload scan failed; accepting file (fail-open)');
+  }
+
+  for (const f of allFiles) moveFile('verification', escrowId, f);
 
-  const files = req.files as { [field: string]: Express.Multer.File[] };
   const docs = (files['document'] || []).map(f => f.path);
   const selfie = (files['selfie']?.[0]?.path) || null;
 
@@ -1,10 +1,54 @@
 import { Router } from 'express';
+import { z } from 'zod';
+import type { Logger } from 'pino';
 import { prisma } from '../db/client';
+import { env } from '../config/env';
 import { getContracts } from '../contracts';
 import { authMiddleware } from '../middleware/auth';
+import { rateLimit } from '../middleware/rateLimit';
+import { decodeCursor, keysetPage } from '../utils/cursor';
+import { sendError } from '../utils/errors';
+import { FieldSchema, parseFieldsParam, pickFields } from '../utils/fields';
 
 const router = Router();
 
+// Listing resolves on-chain metadata per verifier, so reads are limited too.
+const readLimit = rateLimit('verifiers', { perMinute: env.RATE_LIMIT_READS_PER_MIN });
+
+const verifierFields: FieldSchema = {
+  id: true,
+  name: true,
+  onchainAddress: true,
+  fee: true,
+  currency: true,
+  rating: true,
+  status: true,
+  metadata: true,
+  createdAt: true,
+  onchain: {
+    name: true,
+    metadataURI: true,
+    baseFee: true,
+    isActive: true,
+    reputationScore: true,
+    totalVerifications: true,
+    successfulVerifications: true,
+    stakedAmount: true,
+    lastActivityTimestamp: true,
+  },
+  onchainResolved: { name: true },
+};
+
+const listQuerySchema = z.object({
+  mode: z.enum(['all', 'cursor']).optional(),
+  cursor: z.string().optional(),
+});
+
+// `limit` only applies to cursor mode; `all` ignores it so existing callers are unaffected.
+const pageQuerySchema = z.object({
+  limit: z.coerce.number().int().min(1).max(100).default(20),
+});
+
 function serializeVerifier(v: any) {
   return {
     ...v,
@@ -12,8 +56,8 @@ function serializeVerifier(v: any) {
   };
 }
 
-async function fetchOnchainMetadata(verifierAddress: string) {
-  const { marketplace } = getContracts();
+async function fetchOnchainMetadata(verifierAddress: string, log: Logger) {
+  const { marketplace } = getContracts(log);
   try {
     // Use the new getVerifierDetails function for comprehensive data
     const [name, metadataURI, baseFee, isActive, reputationScore, totalVerifications, successfulVerifications, stakedAmount] = 
@@ -53,43 +97,52 @@ async function fetchOnchainMetadata(verifierAddress: string) {
   }
 }
 
-router.get('/', authMiddleware, async (_req, res) => {
-  const dbVerifiers = await prisma.verifier.findMany({ orderBy: { createdAt: 'desc' } });
-  
-  const withOnchain = await Promise.all(dbVerifiers.map(async (v) => {
-    const onchainData = await fetchOnchainMetadata(v.onchainAddress);
-    
-    if (!onchainData) {
-      return { ...serializeVerifier(v), onchain: null };
-    }
-    
-    return {
-      ...serializeVerifier(v),
-      onchain: onchainData,
-      // Add resolved metadata if name is available from contract
-      ...(onchainData.name && { onchainResolved: { name: onchainData.name } })
-    };
-  }));
-  
-  res.json({ verifiers: withOnchain });
-});
-
-router.get('/:id', authMiddleware, async (req, res) => {
-  const v = await prisma.verifier.findUnique({ where: { id: req.params.id } });
-  if (!v) return res.status(404).json({ error: 'Verifier not found' });
-  
-  const onchainData = await fetchOnchainMetadata(v.onchainAddress);
+async function withOnchain(v: any, log: Logger) {
+  const onchainData = await fetchOnchainMetadata(v.onchainAddress, log);
   
   if (!onchainData) {
-    return res.json({ ...serializeVerifier(v), onchain: null });
+    return { ...serializeVerifier(v), onchain: null };
   }
   
-  return res.json({
+  return {
     ...serializeVerifier(v),
     onchain: onchainData,
     // Add resolved metadata if name is available from contract
     ...(onchainData.name && { onchainResolved: { name: onchainData.name } })
-  });
+  };
+}
+
+router.get('/', authMiddleware, readLimit, async (req, res) => {
+  const parse = listQuerySchema.safeParse(req.query);
+  if (!parse.success) return sendError(req, res, 'VALIDATION_FAILED', parse.error.flatten());
+  const query = parse.data;
+  const mode = query.mode ?? (query.cursor ? 'cursor' : 'all');
+
+  if (mode === 'all') {
+    const dbVerifiers = await prisma.verifier.findMany({ orderBy: { createdAt: 'desc' } });
+    return res.json({ verifiers: await Promise.all(dbVerifiers.map((v) => withOnchain(v, req.log))) });
+  }
+
+  const pageParse = pageQuerySchema.safeParse({ limit: req.query.limit });
+  if (!pageParse.success) return sendError(req, res, 'VALIDATION_FAILED', pageParse.error.flatten());
+  const { limit } = pageParse.data;
+
+  const cursor = query.cursor ? decodeCursor(query.cursor) : null;
+  if (query.cursor && !cursor) return sendError(req, res, 'INVALID_CURSOR');
+
+  const { page, nextCursor } = await keysetPage((args) => prisma.verifier.findMany(args), cursor, limit);
+
+  res.json({ verifiers: await Promise.all(page.map((v) => withOnchain(v, req.log))), next_cursor: nextCursor });
+});
+
+router.get('/:id', authMiddleware, readLimit, async (req, res) => {
+  const { fields, unknown } = parseFieldsParam(req.query.fields, verifierFields);
+  if (unknown.length) return sendError(req, res, 'UNKNOWN_FIELDS', { fields: unknown });
+
+  const v = await prisma.verifier.findUnique({ where: { id: req.params.id } });
+  if (!v) return sendError(req, res, 'VERIFIER_NOT_FOUND');
+  
+  return res.json(pickFields(await withOnchain(v, req.log), fields));
 });
 
 export default router;
\ No newline at end of file
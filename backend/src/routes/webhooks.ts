@@ -0,0 +1,78 @@
+import { Router } from 'express';
+import { randomBytes } from 'crypto';
+import { z } from 'zod';
+import { prisma } from '../db/client';
+import { env } from '../config/env';
+import { authMiddleware } from '../middleware/auth';
+import { rateLimit } from '../middleware/rateLimit';
+import { sendError } from '../utils/errors';
+import { WEBHOOK_EVENTS } from '../webhooks';
+
+const router = Router();
+
+const webhookLimit = rateLimit('webhooks', { perMinute: env.RATE_LIMIT_READS_PER_MIN });
+
+// Plain http is only accepted outside production, for local receivers.
+const registerSchema = z.object({
+  url: z.string().url().refine((u) => u.startsWith('https://') || (env.NODE_ENV !== 'production' && u.startsWith('http://')), {
+    message: 'url must use https',
+  }),
+  events: z.array(z.enum(WEBHOOK_EVENTS)).min(1),
+});
+
+async function currentUser(clerkUserId: string) {
+  return prisma.user.upsert({ where: { clerkUserId }, update: {}, create: { clerkUserId } });
+}
+
+function serializeWebhook(w: { id: string; url: string; events: string[]; createdAt: Date }) {
+  return { id: w.id, url: w.url, events: w.events, created_at: w.createdAt.toISOString() };
+}
+
+// The secret is only ever returned here; receivers use it to check X-Verza-Signature.
+router.post('/', authMiddleware, webhookLimit, async (req, res) => {
+  const parse = registerSchema.safeParse(req.body);
+  if (!parse.success) return sendError(req, res, 'VALIDATION_FAILED', parse.error.flatten());
+
+  const user = await currentUser(req.user!.id);
+  const secret = `whsec_${randomBytes(32).toString('hex')}`;
+  const webhook = await prisma.webhook.create({
+    data: { userId: user.id, url: parse.data.url, events: [...new Set(parse.data.events)], secret },
+  });
+  res.status(201).json({ ...serializeWebhook(webhook), secret });
+});
+
+router.get('/', authMiddleware, webhookLimit, async (req, res) => {
+  const user = await currentUser(req.user!.id);
+  const webhooks = await prisma.webhook.findMany({ where: { userId: user.id }, orderBy: { createdAt: 'desc' } });
+  res.json({ webhooks: webhooks.map(serializeWebhook) });
+});
+
+router.get('/:id/deliveries', authMiddleware, webhookLimit, async (req, res) => {
+  const user = await currentUser(req.user!.id);
+  const webhook = await prisma.webhook.findFirst({ where: { id: req.params.id, userId: user.id } });
+  if (!webhook) return sendError(req, res, 'WEBHOOK_NOT_FOUND');
+
+  const deliveries = await prisma.webhookDelivery.findMany({ where: { webhookId: webhook.id }, orderBy: { createdAt: 'desc' }, take: 50 });
+  res.json({
+    deliveries: deliveries.map((d) => ({
+      id: d.id,
+      event: d.event,
+      status: d.status,
+      attempts: d.attempts,
+      last_status: d.lastStatus,
+      last_error: d.lastError,
+      next_attempt_at: d.status === 'pending' ? d.nextAttemptAt.toISOString() : null,
+      delivered_at: d.deliveredAt?.toISOString() ?? null,
+      created_at: d.createdAt.toISOString(),
+    })),
+  });
+});
+
+router.delete('/:id', authMiddleware, webhookLimit, async (req, res) => {
+  const user = await currentUser(req.user!.id);
+  const { count } = await prisma.webhook.deleteMany({ where: { id: req.params.id, userId: user.id } });
+  if (!count) return sendError(req, res, 'WEBHOOK_NOT_FOUND');
+  res.status(204).end();
+});
+
+export default router;
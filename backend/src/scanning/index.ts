@@ -0,0 +1,80 @@
+import fs from 'fs';
+import net from 'net';
+import { Transform, pipeline } from 'stream';
+import { env } from '../config/env';
+
+export type ScanResult = { clean: true } | { clean: false; signature: string };
+
+export interface FileScanner {
+  name: string;
+  scan(filePath: string): Promise<ScanResult>;
+}
+
+class NoopScanner implements FileScanner {
+  name = 'none';
+  async scan(): Promise<ScanResult> {
+    return { clean: true };
+  }
+}
+
+function instreamFraming(): Transform {
+  return new Transform({
+    transform(chunk: Buffer, _enc, cb) {
+      const len = Buffer.alloc(4);
+      len.writeUInt32BE(chunk.length);
+      cb(null, Buffer.concat([len, chunk]));
+    },
+    flush(cb) {
+      cb(null, Buffer.alloc(4));
+    },
+  });
+}
+
+// Streams the file to clamd using the INSTREAM command: length-prefixed chunks terminated by a zero-length chunk.
+class ClamAVScanner implements FileScanner {
+  name = 'clamav';
+
+  constructor(private host: string, private port: number, private timeoutMs: number) {}
+
+  scan(filePath: string): Promise<ScanResult> {
+    return new Promise((resolve, reject) => {
+      const socket = net.createConnection({ host: this.host, port: this.port });
+      const reply: Buffer[] = [];
+
+      socket.setTimeout(this.timeoutMs, () => socket.destroy(new Error('clamd timed out')));
+      socket.on('error', reject);
+      socket.on('data', (d) => reply.push(d));
+      socket.on('end', () => {
+        // e.g. "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+        const text = Buffer.concat(reply).toString('utf-8').replace(/\0/g, '').trim();
+        if (text.endsWith(' OK')) return resolve({ clean: true });
+        const found = /:\s*(.+)\s+FOUND$/.exec(text);
+        if (found) return resolve({ clean: false, signature: found[1] });
+        reject(new Error(`clamd: ${text || 'empty reply'}`));
+      });
+
+      socket.on('connect', () => {
+        socket.write('zINSTREAM\0');
+        // pipeline honours socket backpressure and destroys the file stream if the socket errors or times out.
+        const file = fs.createReadStream(filePath, { highWaterMark: 64 * 1024 });
+        pipeline(file, instreamFraming(), socket, (err) => {
+          if (err) {
+            socket.destroy();
+            reject(err);
+          }
+        });
+      });
+    });
+  }
+}
+
+let scanner: FileScanner | null = null;
+
+// SCAN_PROVIDER is validated in config/env when the process boots.
+export function getScanner(): FileScanner {
+  if (scanner) return scanner;
+  scanner = env.SCAN_PROVIDER === 'clamav'
+    ? new ClamAVScanner(env.CLAMAV_HOST, env.CLAMAV_PORT, env.SCAN_TIMEOUT_MS)
+    : new NoopScanner();
+  return scanner;
+}
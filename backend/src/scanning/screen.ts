@@ -0,0 +1,36 @@
+import type { ScanFailMode } from '../config/env';
+import type { FileScanner } from './index';
+
+type ScreenedFile = { path: string; originalname: string };
+
+export type ScreenResult<F extends ScreenedFile> =
+  | { ok: true; unscanned: { file: F; err: string }[] }
+  | { ok: false; reason: 'infected'; file: F; signature: string }
+  | { ok: false; reason: 'unavailable'; file: F; err: string };
+
+/**
+ * Scans staged uploads one by one and stops at the first infected file. When the scanner fails, `closed` rejects the
+ * upload and `open` accepts the file, reporting it in `unscanned` so the caller can log it.
+ */
+export async function screenFiles<F extends ScreenedFile>(
+  files: F[],
+  scanner: FileScanner,
+  failMode: ScanFailMode
+): Promise<ScreenResult<F>> {
+  const unscanned: { file: F; err: string }[] = [];
+  for (const file of files) {
+    let result;
+    try {
+      result = await scanner.scan(file.path);
+    } catch (e: any) {
+      const err = String(e?.message ?? e);
+      if (failMode === 'open') {
+        unscanned.push({ file, err });
+        continue;
+      }
+      return { ok: false, reason: 'unavailable', file, err };
+    }
+    if (!result.clean) return { ok: false, reason: 'infected', file, signature: result.signature };
+  }
+  return { ok: true, unscanned };
+}
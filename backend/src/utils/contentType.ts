@@ -0,0 +1,21 @@
+// Number of leading bytes inspected when sniffing an upload's content type.
+export const SNIFF_BYTES = 512;
+
+const signatures: { type: string; bytes: number[] }[] = [
+  { type: 'image/jpeg', bytes: [0xff, 0xd8, 0xff] },
+  { type: 'image/png', bytes: [0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a] },
+  { type: 'application/pdf', bytes: [0x25, 0x50, 0x44, 0x46, 0x2d] }, // %PDF-
+  { type: 'image/gif', bytes: [0x47, 0x49, 0x46, 0x38] },
+  { type: 'application/zip', bytes: [0x50, 0x4b, 0x03, 0x04] },
+  { type: 'application/x-msdownload', bytes: [0x4d, 0x5a] }, // MZ (Windows executables)
+];
+
+// Content types accepted for verification documents and selfies, judged by detectContentType.
+export const ALLOWED_UPLOAD_TYPES = ['image/jpeg', 'image/png', 'application/pdf'];
+
+export function detectContentType(head: Buffer): string {
+  for (const sig of signatures) {
+    if (head.length >= sig.bytes.length && sig.bytes.every((b, i) => head[i] === b)) return sig.type;
+  }
+  return 'application/octet-stream';
+}
@@ -0,0 +1,47 @@
+export type ListCursor = { createdAt: Date; id: string };
+
+// Opaque keyset cursor over (createdAt desc, id desc), matching the list ordering.
+export function encodeCursor(v: ListCursor): string {
+  return Buffer.from(JSON.stringify({ t: v.createdAt.toISOString(), id: v.id })).toString('base64url');
+}
+
+export function decodeCursor(raw: string): ListCursor | null {
+  try {
+    const { t, id } = JSON.parse(Buffer.from(raw, 'base64url').toString('utf-8'));
+    const createdAt = new Date(t);
+    if (typeof id !== 'string' || typeof t !== 'string' || isNaN(createdAt.getTime())) return null;
+    return { createdAt, id };
+  } catch {
+    return null;
+  }
+}
+
+// Prisma filter for rows that sort strictly after the cursor in (createdAt desc, id desc) order.
+export function afterCursor(cursor: ListCursor) {
+  return {
+    OR: [
+      { createdAt: { lt: cursor.createdAt } },
+      { createdAt: cursor.createdAt, id: { lt: cursor.id } },
+    ],
+  };
+}
+
+export const KEYSET_ORDER = [{ createdAt: 'desc' as const }, { id: 'desc' as const }];
+
+export type KeysetQuery = {
+  where?: ReturnType<typeof afterCursor>;
+  orderBy: typeof KEYSET_ORDER;
+  take: number;
+};
+
+// Reads one page after `cursor` through the given findMany. One extra row is fetched to learn whether another page
+// exists; nextCursor points at the last returned row, or is null on the final page.
+export async function keysetPage<T extends ListCursor>(
+  findMany: (args: KeysetQuery) => Promise<T[]>,
+  cursor: ListCursor | null,
+  limit: number
+): Promise<{ page: T[]; nextCursor: string | null }> {
+  const rows = await findMany({ where: cursor ? afterCursor(cursor) : undefined, orderBy: KEYSET_ORDER, take: limit + 1 });
+  const page = rows.slice(0, limit);
+  return { page, nextCursor: rows.length > limit ? encodeCursor(page[page.length - 1]) : null };
+}
@@ -0,0 +1,130 @@
+import { Request, Response } from 'express';
+import { env } from '../config/env';
+
+type ErrorKind =
+  | 'bad_request'
+  | 'validation'
+  | 'unauthorized'
+  | 'not_found'
+  | 'conflict'
+  | 'payload_too_large'
+  | 'unsupported_media_type'
+  | 'unprocessable'
+  | 'too_many_requests'
+  | 'internal'
+  | 'service_unavailable';
+
+const PROBLEM_JSON = 'application/problem+json';
+
+// Problem type URIs are `${PROBLEM_BASE_URI}/<slug>` and must stay stable once published.
+const problemTypes: Record<ErrorKind, { status: number; title: string; slug: string }> = {
+  bad_request: { status: 400, title: 'Bad Request', slug: 'bad-request' },
+  validation: { status: 400, title: 'Validation Failed', slug: 'validation' },
+  unauthorized: { status: 401, title: 'Unauthorized', slug: 'unauthorized' },
+  not_found: { status: 404, title: 'Not Found', slug: 'not-found' },
+  conflict: { status: 409, title: 'Conflict', slug: 'conflict' },
+  payload_too_large: { status: 413, title: 'Payload Too Large', slug: 'payload-too-large' },
+  unsupported_media_type: { status: 415, title: 'Unsupported Media Type', slug: 'unsupported-media-type' },
+  unprocessable: { status: 422, title: 'Unprocessable Content', slug: 'unprocessable' },
+  too_many_requests: { status: 429, title: 'Too Many Requests', slug: 'too-many-requests' },
+  internal: { status: 500, title: 'Internal Server Error', slug: 'internal' },
+  service_unavailable: { status: 503, title: 'Service Unavailable', slug: 'service-unavailable' },
+};
+
+// Error codes are part of the API contract: clients branch on them, so never rename or repurpose one.
+const errorCatalog = {
+  BAD_REQUEST: { kind: 'bad_request', message: 'Bad request' },
+  MALFORMED_JSON: { kind: 'bad_request', message: 'Malformed JSON body' },
+  PAYLOAD_TOO_LARGE: { kind: 'payload_too_large', message: 'Request body too large' },
+  UNSUPPORTED_ENCODING: { kind: 'unsupported_media_type', message: 'Unsupported request body encoding' },
+  VALIDATION_FAILED: { kind: 'validation', message: 'Invalid request' },
+  UNKNOWN_FIELDS: { kind: 'validation', message: 'Unknown fields' },
+  INVALID_CURSOR: { kind: 'validation', message: 'Invalid cursor' },
+  ESCROW_ID_REQUIRED: { kind: 'validation', message: 'escrowId required' },
+  INVALID_ESCROW_ID: { kind: 'validation', message: 'escrowId must be a 0x-prefixed 32-byte hex string' },
+  WALLET_ADDRESS_REQUIRED: { kind: 'validation', message: 'Missing user wallet_address for non-custodial flow' },
+  FEE_CALCULATION_FAILED: { kind: 'bad_request', message: 'Failed to calculate verification fee' },
+  MISSING_TOKEN: { kind: 'unauthorized', message: 'Missing Bearer token' },
+  INVALID_TOKEN: { kind: 'unauthorized', message: 'Invalid token' },
+  ESCROW_NOT_FOUND: { kind: 'not_found', message: 'Escrow not found' },
+  VERIFIER_NOT_FOUND: { kind: 'not_found', message: 'Verifier not found' },
+  WEBHOOK_NOT_FOUND: { kind: 'not_found', message: 'Webhook not found' },
+  ESCROW_CONFLICT: { kind: 'conflict', message: 'Escrow already exists for another user' },
+  FILE_TOO_LARGE: { kind: 'payload_too_large', message: 'File too large' },
+  TOO_MANY_FILES: { kind: 'validation', message: 'Too many files' },
+  UNEXPECTED_FILE_FIELD: { kind: 'validation', message: 'Unexpected file field' },
+  INVALID_MULTIPART: { kind: 'validation', message: 'Invalid multipart form' },
+  UNSUPPORTED_FILE_TYPE: { kind: 'unsupported_media_type', message: 'Unsupported file type' },
+  MALWARE_DETECTED: { kind: 'unprocessable', message: 'File rejected by malware scan' },
+  RATE_LIMITED: { kind: 'too_many_requests', message: 'Too many requests, slow down' },
+  AUTH_NOT_CONFIGURED: { kind: 'internal', message: 'Auth not configured' },
+  SIGNER_NOT_CONFIGURED: { kind: 'internal', message: 'Server signer not configured' },
+  ESCROW_SUBMISSION_FAILED: { kind: 'internal', message: 'Escrow submission failed' },
+  INTERNAL_ERROR: { kind: 'internal', message: 'Internal Server Error' },
+  SCAN_UNAVAILABLE: { kind: 'service_unavailable', message: 'File scanning unavailable, try again later' },
+  NETWORK_FEE_TOO_HIGH: { kind: 'service_unavailable', message: 'Network fees are above the configured cap, try again later' },
+  SPEND_BUDGET_EXCEEDED: { kind: 'service_unavailable', message: 'Server transaction budget exhausted, try again later' },
+} satisfies Record<string, { kind: ErrorKind; message: string }>;
+
+export type ErrorCode = keyof typeof errorCatalog;
+
+/** Error body returned by every endpoint unless the client asked for problem+json. */
+export interface APIError {
+  /** Stable machine-readable code from the catalog above. */
+  code: ErrorCode;
+  /** Human-readable message; may change between releases. */
+  message: string;
+  /** Optional structured context, e.g. validation issues or the offending file. */
+  details?: unknown;
+  /** @deprecated Same value as `message`; kept for clients written against the old `{ error }` envelope. */
+  error: string;
+}
+
+// Maps an error thrown by middleware to a catalog code. body-parser tags its errors with `type` and a 4xx `status`;
+// any other client error keeps a 4xx response, and everything else is a 500.
+export function codeForThrown(err: any): ErrorCode {
+  switch (err?.type) {
+    case 'entity.parse.failed':
+      return 'MALFORMED_JSON';
+    case 'entity.too.large':
+    case 'parameters.too.many':
+      return 'PAYLOAD_TOO_LARGE';
+    case 'encoding.unsupported':
+    case 'charset.unsupported':
+      return 'UNSUPPORTED_ENCODING';
+  }
+  const status = Number(err?.status ?? err?.statusCode);
+  if (status === 413) return 'PAYLOAD_TOO_LARGE';
+  if (status >= 400 && status < 500) return 'BAD_REQUEST';
+  return 'INTERNAL_ERROR';
+}
+
+function wantsProblemJson(req: Request): boolean {
+  return req.accepts(['application/json', PROBLEM_JSON]) === PROBLEM_JSON;
+}
+
+/**
+ * Sends an APIError with the status and message mapped from its code, or RFC 7807 problem+json when the client asks
+ * for it via Accept.
+ */
+export function sendError(req: Request, res: Response, code: ErrorCode, details?: unknown) {
+  const { kind, message } = errorCatalog[code];
+  const problem = problemTypes[kind];
+
+  if (!wantsProblemJson(req)) {
+    const body: APIError = { code, message, ...(details !== undefined && { details }), error: message };
+    return res.status(problem.status).json(body);
+  }
+  return res
+    .status(problem.status)
+    .type(PROBLEM_JSON)
+    .json({
+      type: `${env.PROBLEM_BASE_URI}/${problem.slug}`,
+      title: problem.title,
+      status: problem.status,
+      detail: message,
+      instance: req.originalUrl,
+      code,
+      ...(details !== undefined && { details }),
+    });
+}
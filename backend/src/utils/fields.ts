@@ -0,0 +1,60 @@
+// Describes which response fields a client may select via `?fields=`.
+// `true` marks a leaf; a nested schema allows dotted selection (e.g. `onchain.reputationScore`).
+export type FieldSchema = { [field: string]: true | FieldSchema };
+
+type FieldTree = { [field: string]: true | FieldTree };
+
+function has(obj: object, key: string): boolean {
+  return Object.prototype.hasOwnProperty.call(obj, key);
+}
+
+function inSchema(schema: FieldSchema, path: string[]): boolean {
+  let node: true | FieldSchema = schema;
+  for (const part of path) {
+    if (node === true || !has(node, part)) return false;
+    node = node[part];
+  }
+  return true;
+}
+
+export function parseFieldsParam(raw: unknown, schema: FieldSchema): { fields: string[] | null; unknown: string[] } {
+  if (raw === undefined || raw === '') return { fields: null, unknown: [] };
+  const joined = Array.isArray(raw) ? raw.join(',') : String(raw);
+  const fields = joined.split(',').map((f) => f.trim()).filter(Boolean);
+  const unknown = fields.filter((f) => !inSchema(schema, f.split('.')));
+  return { fields: fields.length ? fields : null, unknown };
+}
+
+function buildTree(fields: string[]): FieldTree {
+  const tree: FieldTree = {};
+  for (const field of fields) {
+    const parts = field.split('.');
+    let node = tree;
+    for (let i = 0; i < parts.length; i++) {
+      const part = parts[i];
+      if (i === parts.length - 1) {
+        node[part] = true; // selecting a parent selects its whole subtree
+        break;
+      }
+      if (node[part] === true) break;
+      if (!has(node, part)) node[part] = {};
+      node = node[part] as FieldTree;
+    }
+  }
+  return tree;
+}
+
+function pick(value: any, tree: true | FieldTree): any {
+  if (tree === true || value === null || value === undefined) return value;
+  if (Array.isArray(value)) return value.map((v) => pick(v, tree));
+  if (typeof value !== 'object') return value;
+  const out: Record<string, any> = {};
+  for (const [key, sub] of Object.entries(tree)) {
+    if (has(value, key)) out[key] = pick(value[key], sub);
+  }
+  return out;
+}
+
+export function pickFields<T extends object>(body: T, fields: string[] | null): Partial<T> {
+  return fields ? pick(body, buildTree(fields)) : body;
+}
@@ -0,0 +1,15 @@
+import type { IncomingMessage, ServerResponse } from 'http';
+
+/**
+ * Builds a morgan `skip` predicate that logs only `rate` (0..1) of successful (2xx/3xx) responses. Responses with
+ * status >= 400 are always logged. Sampling is deterministic, so a rate of 0.1 logs exactly every tenth success.
+ */
+export function sampleSuccessfulRequests(rate: number): (req: IncomingMessage, res: ServerResponse) => boolean {
+  let seen = 0;
+  return (_req, res) => {
+    if (res.statusCode >= 400 || rate >= 1) return false;
+    seen++;
+    // Log whenever the running total of sampled requests, floor(seen * rate), ticks over.
+    return Math.floor(seen * rate) === Math.floor((seen - 1) * rate);
+  };
+}
@@ -0,0 +1,121 @@
+import { createHmac, randomUUID } from 'crypto';
+import { PrismaClient } from '@prisma/client';
+import { env } from '../config/env';
+import { logger } from '../logger';
+import { prisma } from '../db/client';
+
+export const WEBHOOK_EVENTS = ['escrow.funded', 'escrow.released', 'escrow.refunded', 'escrow.cancelled', 'credential.issued'] as const;
+export type WebhookEvent = (typeof WEBHOOK_EVENTS)[number];
+
+export const SIGNATURE_HEADER = 'X-Verza-Signature';
+
+type WebhookDb = Pick<PrismaClient, 'webhook' | 'webhookDelivery'>;
+type Send = (url: string, init: { method: string; headers: Record<string, string>; body: string; signal: AbortSignal }) => Promise<{ status: number }>;
+
+/** `sha256=<hex>` HMAC of the exact request body, keyed with the webhook secret. */
+export function signPayload(secret: string, body: string): string {
+  return `sha256=${createHmac('sha256', secret).update(body).digest('hex')}`;
+}
+
+// 30s, 1m, 2m, … capped at an hour.
+export function retryDelayMs(attempts: number): number {
+  return Math.min(30_000 * 2 ** (attempts - 1), 60 * 60 * 1000);
+}
+
+type DeliveryUpdate = {
+  status: 'pending' | 'delivered' | 'failed';
+  attempts: number;
+  lastStatus: number | null;
+  lastError: string | null;
+  nextAttemptAt?: Date;
+  deliveredAt?: Date;
+};
+
+/** POSTs one delivery and returns how its row should change. Never throws. */
+export async function attemptDelivery(
+  webhook: { url: string; secret: string },
+  delivery: { id: string; event: string; payload: unknown; attempts: number },
+  send: Send = fetch,
+  now = new Date()
+): Promise<DeliveryUpdate> {
+  const body = JSON.stringify(delivery.payload);
+  const attempts = delivery.attempts + 1;
+  let lastStatus: number | null = null;
+  let lastError: string | null = null;
+  try {
+    const res = await send(webhook.url, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        'X-Verza-Event': delivery.event,
+        'X-Verza-Delivery': delivery.id,
+        [SIGNATURE_HEADER]: signPayload(webhook.secret, body),
+      },
+      body,
+      signal: AbortSignal.timeout(env.WEBHOOK_TIMEOUT_MS),
+    });
+    lastStatus = res.status;
+    if (res.status >= 200 && res.status < 300) {
+      return { status: 'delivered', attempts, lastStatus, lastError, deliveredAt: now };
+    }
+    lastError = `HTTP ${res.status}`;
+  } catch (e: any) {
+    lastError = e?.message ?? String(e);
+  }
+  if (attempts >= env.WEBHOOK_MAX_ATTEMPTS) return { status: 'failed', attempts, lastStatus, lastError };
+  return { status: 'pending', attempts, lastStatus, lastError, nextAttemptAt: new Date(now.getTime() + retryDelayMs(attempts)) };
+}
+
+/** Queues `event` for every webhook of `userId` subscribed to it. Failures are logged, never thrown to the caller. */
+export async function publishEvent(userId: string, event: WebhookEvent, data: Record<string, unknown>, db: WebhookDb = prisma) {
+  try {
+    const hooks = await db.webhook.findMany({ where: { userId, events: { has: event } }, select: { id: true } });
+    if (!hooks.length) return;
+    const payload = { id: `evt_${randomUUID()}`, type: event, created_at: new Date().toISOString(), data };
+    await db.webhookDelivery.createMany({ data: hooks.map((h) => ({ webhookId: h.id, event, payload })) });
+  } catch (e: any) {
+    logger.error({ userId, event, err: e?.message }, 'Failed to queue webhook event');
+  }
+}
+
+/** Sends every due delivery once. Rows are leased first so replicas polling at the same time don't double-send. */
+export async function deliverDue(db: WebhookDb = prisma, send: Send = fetch) {
+  const now = new Date();
+  const due = await db.webhookDelivery.findMany({
+    where: { status: 'pending', nextAttemptAt: { lte: now } },
+    include: { webhook: true },
+    orderBy: { nextAttemptAt: 'asc' },
+    take: 20,
+  });
+  for (const delivery of due) {
+    const lease = new Date(now.getTime() + 2 * env.WEBHOOK_TIMEOUT_MS);
+    const { count } = await db.webhookDelivery.updateMany({
+      where: { id: delivery.id, status: 'pending', nextAttemptAt: delivery.nextAttemptAt },
+      data: { nextAttemptAt: lease },
+    });
+    if (!count) continue;
+
+    const update = await attemptDelivery(delivery.webhook, delivery, send);
+    await db.webhookDelivery.update({ where: { id: delivery.id }, data: update });
+    const log = { deliveryId: delivery.id, webhookId: delivery.webhookId, event: delivery.event, attempts: update.attempts, err: update.lastError };
+    if (update.status === 'failed') logger.error(log, 'Webhook delivery failed permanently');
+    else if (update.status === 'pending') logger.warn(log, 'Webhook delivery failed; will retry');
+  }
+}
+
+export function startWebhookWorker() {
+  let running = false;
+  const timer = setInterval(async () => {
+    if (running) return;
+    running = true;
+    try {
+      await deliverDue();
+    } catch (e: any) {
+      logger.error({ err: e?.message }, 'Webhook delivery pass failed');
+    } finally {
+      running = false;
+    }
+  }, env.WEBHOOK_POLL_MS);
+  timer.unref();
+  logger.info({ pollMs: env.WEBHOOK_POLL_MS }, 'Webhook worker started');
+}
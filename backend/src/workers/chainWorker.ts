@@ -1,6 +1,8 @@
 import { getContracts } from '../contracts';
+import { signerSpend, SpendRefusedError } from '../contracts/spend';
 import { logger } from '../logger';
 import { prisma } from '../db/client';
+import { publishEvent } from '../webhooks';
 import { keccak256, toUtf8Bytes } from 'ethers';
 
 export async function startChainWorker() {
@@ -31,11 +33,12 @@ export async function startChainWorker() {
 
   escrow.on('FundsLocked', async (requestId: string, amount: bigint, expiresAt: bigint) => {
     try {
-      await prisma.escrow.update({
+      const record = await prisma.escrow.update({
         where: { id: requestId },
         data: { status: 'in_progress', autoReleaseAt: new Date(Number(expiresAt) * 1000) },
       });
       logger.info({ requestId }, 'FundsLocked processed');
+      await publishEvent(record.userId, 'escrow.funded', { escrowId: requestId, status: record.status, amount: amount.toString() });
     } catch (e) {
       logger.error({ e, requestId }, 'Failed to process FundsLocked');
     }
@@ -46,6 +49,7 @@ export async function startChainWorker() {
       // Mark escrow completed
       const escrowRecord = await prisma.escrow.update({ where: { id: requestId }, data: { status: 'completed' }, include: { user: true, credential: true } });
       logger.info({ requestId }, 'FundsReleased processed');
+      await publishEvent(escrowRecord.userId, 'escrow.released', { escrowId: requestId, status: escrowRecord.status });
 
       // Attempt VC issuance when settlement completes
       if (!signer) {
@@ -85,6 +89,7 @@ export async function startChainWorker() {
       const metadataURI = `data:application/json;base64,${Buffer.from(JSON.stringify(meta)).toString('base64')}`;
 
       try {
+        const { overrides, settle } = await signerSpend.authorize(provider);
         const tx = await registry.issueCredential(
           holder,
           hederaDID,
@@ -93,9 +98,11 @@ export async function startChainWorker() {
           metadataURI,
           '', // schemaURI empty to avoid approval requirement
           [], // claims
-          0   // expirationPeriod (0 => default or none)
+          0,  // expirationPeriod (0 => default or none)
+          overrides
         );
         const receipt = await tx.wait();
+        settle(receipt);
 
         // Parse logs to extract tokenId
         let tokenId: bigint | null = null;
@@ -123,7 +130,12 @@ export async function startChainWorker() {
           }
         });
         logger.info({ requestId, tokenId: tokenId?.toString() }, 'VC issuance persisted');
+        await publishEvent(escrowRecord.userId, 'credential.issued', { escrowId: requestId, tokenId: tokenId?.toString() ?? null });
       } catch (e: any) {
+        if (e instanceof SpendRefusedError) {
+          logger.error({ alert: 'signer_spend_refused', reason: e.reason, requestId, err: e.message }, 'Refused VC issuance');
+          return;
+        }
         logger.error({ requestId, err: e?.message }, 'VC issuance failed');
       }
     } catch (e) {
@@ -133,8 +145,9 @@ export async function startChainWorker() {
 
   escrow.on('RefundIssued', async (requestId: string) => {
     try {
-      await prisma.escrow.update({ where: { id: requestId }, data: { status: 'refunded' } });
+      const record = await prisma.escrow.update({ where: { id: requestId }, data: { status: 'refunded' } });
       logger.info({ requestId }, 'RefundIssued processed');
+      await publishEvent(record.userId, 'escrow.refunded', { escrowId: requestId, status: record.status });
     } catch (e) {
       logger.error({ e, requestId }, 'Failed to process RefundIssued');
     }
@@ -142,8 +155,9 @@ export async function startChainWorker() {
 
   escrow.on('EscrowCancelled', async (requestId: string) => {
     try {
-      await prisma.escrow.update({ where: { id: requestId }, data: { status: 'cancelled' } });
+      const record = await prisma.escrow.update({ where: { id: requestId }, data: { status: 'cancelled' } });
       logger.info({ requestId }, 'EscrowCancelled processed');
+      await publishEvent(record.userId, 'escrow.cancelled', { escrowId: requestId, status: record.status });
     } catch (e) {
       logger.error({ e, requestId }, 'Failed to process EscrowCancelled');
     }
@@ -0,0 +1,67 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import multer from 'multer';
+import { env } from '../src/config/env';
+import { multerErrorResponse } from '../src/routes/uploads';
+import { ALLOWED_UPLOAD_TYPES, detectContentType } from '../src/utils/contentType';
+import { sendError } from '../src/utils/errors';
+import { fakeReq, fakeRes } from './fakes/http';
+
+const head = (...bytes: number[]) => Buffer.concat([Buffer.from(bytes), Buffer.alloc(32, 0x41)]);
+
+test('JPEG, PNG and PDF are recognised by their bytes and accepted', () => {
+  const cases: [Buffer, string][] = [
+    [head(0xff, 0xd8, 0xff, 0xe0), 'image/jpeg'],
+    [head(0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a), 'image/png'],
+    [Buffer.from('%PDF-1.7\n'), 'application/pdf'],
+  ];
+  for (const [bytes, type] of cases) {
+    assert.equal(detectContentType(bytes), type);
+    assert.ok(ALLOWED_UPLOAD_TYPES.includes(type), type);
+  }
+});
+
+test('executables, archives and empty files are rejected', () => {
+  const cases: [Buffer, string][] = [
+    [head(0x4d, 0x5a, 0x90, 0x00), 'application/x-msdownload'],
+    [head(0x50, 0x4b, 0x03, 0x04), 'application/zip'],
+    [Buffer.alloc(0), 'application/octet-stream'],
+    // A truncated signature must not match.
+    [Buffer.from([0x89, 0x50, 0x4e]), 'application/octet-stream'],
+  ];
+  for (const [bytes, type] of cases) {
+    assert.equal(detectContentType(bytes), type);
+    assert.ok(!ALLOWED_UPLOAD_TYPES.includes(type), type);
+  }
+});
+
+function respond(err: multer.MulterError) {
+  const { req } = fakeReq();
+  const { res, out } = fakeRes();
+  const { code, details } = multerErrorResponse(err);
+  sendError(req, res, code, details);
+  return out;
+}
+
+test('oversized files map to 413 FILE_TOO_LARGE with the limit', () => {
+  const out = respond(new multer.MulterError('LIMIT_FILE_SIZE', 'document'));
+  assert.equal(out.status, 413);
+  assert.equal(out.body.code, 'FILE_TOO_LARGE');
+  assert.deepEqual(out.body.details, { max_bytes: env.MAX_UPLOAD_BYTES });
+});
+
+test('other multer errors map to 400 with the field and reason', () => {
+  const cases: [string, string][] = [
+    ['LIMIT_FILE_COUNT', 'TOO_MANY_FILES'],
+    ['LIMIT_PART_COUNT', 'TOO_MANY_FILES'],
+    ['LIMIT_UNEXPECTED_FILE', 'UNEXPECTED_FILE_FIELD'],
+    ['MISSING_FIELD_NAME', 'UNEXPECTED_FILE_FIELD'],
+    ['LIMIT_FIELD_VALUE', 'INVALID_MULTIPART'],
+  ];
+  for (const [multerCode, code] of cases) {
+    const out = respond(new multer.MulterError(multerCode as multer.ErrorCode, 'selfie'));
+    assert.equal(out.status, 400, multerCode);
+    assert.equal(out.body.code, code, multerCode);
+    assert.deepEqual(out.body.details, { field: 'selfie', reason: multerCode });
+  }
+});
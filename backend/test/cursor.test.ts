@@ -0,0 +1,71 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import { decodeCursor, encodeCursor, KEYSET_ORDER, keysetPage, KeysetQuery, ListCursor } from '../src/utils/cursor';
+
+test('cursor round-trips', () => {
+  const c = { createdAt: new Date('2025-01-02T03:04:05.678Z'), id: 'clx123' };
+  assert.deepEqual(decodeCursor(encodeCursor(c)), c);
+});
+
+test('malformed cursors are rejected', () => {
+  const enc = (v: unknown) => Buffer.from(JSON.stringify(v)).toString('base64url');
+  for (const raw of ['', 'not-base64!', enc('x'), enc({ id: 'a' }), enc({ t: 'yesterday', id: 'a' }), enc({ t: 0, id: 'a' }), enc({ t: '2025-01-01T00:00:00Z', id: 7 })]) {
+    assert.equal(decodeCursor(raw), null, raw);
+  }
+});
+
+type Row = ListCursor;
+
+// In-memory stand-in for prisma.verifier.findMany: evaluates the afterCursor filter, ordering and take the way
+// Postgres would.
+function fakeFindMany(table: Row[], calls: KeysetQuery[] = []) {
+  return async (args: KeysetQuery): Promise<Row[]> => {
+    calls.push(args);
+    const matches = (row: Row) =>
+      !args.where ||
+      args.where.OR.some((clause) =>
+        'id' in clause && clause.id
+          ? row.createdAt.getTime() === (clause.createdAt as Date).getTime() && row.id < clause.id.lt
+          : row.createdAt < (clause.createdAt as { lt: Date }).lt
+      );
+    return table
+      .filter(matches)
+      .sort((a, b) => b.createdAt.getTime() - a.createdAt.getTime() || (a.id < b.id ? 1 : a.id > b.id ? -1 : 0))
+      .slice(0, args.take);
+  };
+}
+
+test('keysetPage fetches one extra row and only returns a cursor when more rows exist', async () => {
+  const base = Date.parse('2025-01-01T00:00:00Z');
+  const table: Row[] = Array.from({ length: 4 }, (_, i) => ({ createdAt: new Date(base + i * 1000), id: `v${i}` }));
+  const calls: KeysetQuery[] = [];
+
+  const first = await keysetPage(fakeFindMany(table, calls), null, 3);
+  assert.deepEqual(first.page.map((r) => r.id), ['v3', 'v2', 'v1']);
+  assert.deepEqual(calls[0], { where: undefined, orderBy: KEYSET_ORDER, take: 4 });
+  assert.deepEqual(decodeCursor(first.nextCursor!), table[1]);
+
+  const last = await keysetPage(fakeFindMany(table), decodeCursor(first.nextCursor!), 3);
+  assert.deepEqual(last, { page: [table[0]], nextCursor: null });
+});
+
+test('paginating while rows are inserted yields no skips or duplicates', async () => {
+  const base = Date.parse('2025-01-01T00:00:00Z');
+  // Several rows share a createdAt so the id tiebreak is exercised.
+  const table: Row[] = Array.from({ length: 23 }, (_, i) => ({ createdAt: new Date(base + Math.floor(i / 3) * 1000), id: `v${String(i).padStart(2, '0')}` }));
+  const original = new Set(table.map((r) => r.id));
+
+  const seen: string[] = [];
+  let cursor: string | null = null;
+  let inserted = 0;
+  do {
+    const { page, nextCursor }: { page: Row[]; nextCursor: string | null } = await keysetPage(fakeFindMany(table), cursor ? decodeCursor(cursor) : null, 5);
+    seen.push(...page.map((r) => r.id));
+    cursor = nextCursor;
+    // New verifiers are newer than anything listed so far and must not disturb later pages.
+    table.push({ createdAt: new Date(base + 60_000 + inserted * 1000), id: `new${inserted++}` });
+  } while (cursor);
+
+  assert.equal(new Set(seen).size, seen.length, 'duplicate rows across pages');
+  assert.deepEqual(new Set(seen), original);
+});
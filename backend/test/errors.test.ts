@@ -0,0 +1,68 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import type { Request, Response } from 'express';
+import { codeForThrown, sendError } from '../src/utils/errors';
+
+function fakeReq(accept: string, url = '/verifiers/v1'): Request {
+  return {
+    originalUrl: url,
+    accepts: (types: string[]) => (accept === '*/*' ? types[0] : types.find((t) => accept.includes(t)) ?? false),
+  } as unknown as Request;
+}
+
+function fakeRes() {
+  const out: { status?: number; type?: string; body?: any } = {};
+  const res = {
+    status(code: number) { out.status = code; return res; },
+    type(t: string) { out.type = t; return res; },
+    json(body: any) { out.body = body; return res; },
+  };
+  return { res: res as unknown as Response, out };
+}
+
+test('validation error is rendered as problem+json when requested', () => {
+  const { res, out } = fakeRes();
+  const issues = [{ path: ['limit'], message: 'Too big' }];
+  sendError(fakeReq('application/problem+json', '/verifiers?limit=500'), res, 'VALIDATION_FAILED', issues);
+  assert.equal(out.status, 400);
+  assert.equal(out.type, 'application/problem+json');
+  assert.deepEqual(out.body, {
+    type: 'https://verza.io/problems/validation',
+    title: 'Validation Failed',
+    status: 400,
+    detail: 'Invalid request',
+    instance: '/verifiers?limit=500',
+    code: 'VALIDATION_FAILED',
+    details: issues,
+  });
+});
+
+test('not-found error is rendered as problem+json when requested', () => {
+  const { res, out } = fakeRes();
+  sendError(fakeReq('application/problem+json'), res, 'VERIFIER_NOT_FOUND');
+  assert.equal(out.status, 404);
+  assert.equal(out.type, 'application/problem+json');
+  assert.deepEqual(out.body, {
+    type: 'https://verza.io/problems/not-found',
+    title: 'Not Found',
+    status: 404,
+    detail: 'Verifier not found',
+    instance: '/verifiers/v1',
+    code: 'VERIFIER_NOT_FOUND',
+  });
+});
+
+test('plain JSON clients get the APIError envelope', () => {
+  const { res, out } = fakeRes();
+  sendError(fakeReq('*/*'), res, 'VERIFIER_NOT_FOUND');
+  assert.equal(out.status, 404);
+  assert.equal(out.type, undefined);
+  assert.deepEqual(out.body, { code: 'VERIFIER_NOT_FOUND', message: 'Verifier not found', error: 'Verifier not found' });
+});
+
+test('body-parser errors keep their 4xx code', () => {
+  assert.equal(codeForThrown({ type: 'entity.parse.failed', status: 400 }), 'MALFORMED_JSON');
+  assert.equal(codeForThrown({ type: 'entity.too.large', status: 413 }), 'PAYLOAD_TOO_LARGE');
+  assert.equal(codeForThrown({ status: 405 }), 'BAD_REQUEST');
+  assert.equal(codeForThrown(new Error('boom')), 'INTERNAL_ERROR');
+});
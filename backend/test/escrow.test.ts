@@ -0,0 +1,160 @@
+import { test, beforeEach } from 'node:test';
+import assert from 'node:assert/strict';
+import { env } from '../src/config/env';
+import { SpendGuard } from '../src/contracts/spend';
+import { EscrowDeps, initiateEscrow } from '../src/routes/escrow';
+import { FAKE_GAS_USED, FakeChain } from './fakes/chain';
+import { FakeDb } from './fakes/db';
+import { fakeReq, fakeRes } from './fakes/http';
+
+const VERIFIER = '0x0000000000000000000000000000000000000be1';
+const FEE = 5n * 10n ** 18n;
+const GWEI = 10n ** 9n;
+const limits = { maxFeePerGas: 50n * GWEI, gasLimit: 300_000n, budget: 100n * 10n ** 18n, periodMs: 60_000 };
+
+let chain: FakeChain;
+let db: FakeDb;
+let verifierId: string;
+
+beforeEach(() => {
+  chain = new FakeChain();
+  chain.verifierFees.set(VERIFIER, FEE);
+  db = new FakeDb();
+  verifierId = db.addVerifier({ onchainAddress: VERIFIER }).id;
+});
+
+function initiate(userId: string, body: Record<string, unknown> = {}, overrides: Partial<EscrowDeps> = {}) {
+  const deps = { db, contracts: () => chain.contracts(), spend: new SpendGuard(limits), ...overrides } as unknown as EscrowDeps;
+  const { req } = fakeReq({ user: { id: userId }, body: { verifier_id: verifierId, ...body } });
+  const { res, out } = fakeRes();
+  return initiateEscrow(deps)(req, res).then(() => out);
+}
+
+test('custodial initiate pays the fee from the server wallet and records the escrow', async () => {
+  env.ESCROW_MODE = 'custodial';
+  const before = chain.balanceOf(FakeChain.SIGNER);
+
+  const out = await initiate('clerk_alice');
+
+  assert.equal(out.status, 200);
+  const [call] = chain.callsTo('createEscrow');
+  assert.deepEqual(call.args, [out.body.escrow_id, VERIFIER]);
+  assert.equal(call.overrides.value, FEE);
+  assert.equal(call.overrides.gasLimit, limits.gasLimit);
+  assert.equal(call.overrides.maxFeePerGas, chain.feeData.maxFeePerGas);
+  assert.equal(out.body.tx_hash, '0x' + '1'.padStart(64, '0'));
+  assert.equal(chain.balanceOf(FakeChain.ADDRESSES.escrow), FEE);
+  assert.equal(chain.balanceOf(FakeChain.SIGNER), before - FEE - FAKE_GAS_USED * chain.feeData.maxFeePerGas);
+  assert.deepEqual(chain.events.map((e) => e.name), ['EscrowCreated']);
+
+  const row = db.escrows.get(out.body.escrow_id);
+  assert.equal(row?.txHash, out.body.tx_hash);
+  assert.equal(row?.verifierId, verifierId);
+  assert.equal(db.users.get(row?.userId)?.clerkUserId, 'clerk_alice');
+});
+
+test('non-custodial initiate returns an unsigned tx and sends nothing', async () => {
+  env.ESCROW_MODE = 'noncustodial';
+
+  const out = await initiate('clerk_bob', { wallet_address: '0x00000000000000000000000000000000000000b0' });
+
+  assert.equal(out.status, 200);
+  assert.equal(out.body.tx.to, FakeChain.ADDRESSES.escrow);
+  assert.equal(out.body.tx.value, FEE.toString());
+  assert.equal(chain.calls.length, 0);
+  assert.ok(db.escrows.has(out.body.escrow_id));
+});
+
+test('a reverted createEscrow reports a submission failure and stores nothing', async () => {
+  env.ESCROW_MODE = 'custodial';
+  chain.failNext('createEscrow');
+
+  const out = await initiate('clerk_alice');
+
+  assert.equal(out.status, 500);
+  assert.equal(out.body.code, 'ESCROW_SUBMISSION_FAILED');
+  assert.equal(db.escrows.size, 0);
+});
+
+test('two users initiating in the same millisecond get different escrow ids', async (t) => {
+  env.ESCROW_MODE = 'custodial';
+  t.mock.timers.enable({ apis: ['Date'], now: Date.parse('2025-06-01T12:00:00Z') });
+
+  const [a, b] = await Promise.all([initiate('clerk_alice'), initiate('clerk_bob')]);
+
+  assert.equal(a.status, 200);
+  assert.equal(b.status, 200);
+  assert.notEqual(a.body.escrow_id, b.body.escrow_id);
+  assert.equal(db.escrows.size, 2);
+});
+
+test('concurrent initiates that collide on an id store one escrow and return a clean 409', async (t) => {
+  env.ESCROW_MODE = 'custodial';
+  t.mock.timers.enable({ apis: ['Date'], now: Date.parse('2025-06-01T12:00:00Z') });
+  const sameNonce = { nonce: () => 42n };
+
+  const results = await Promise.all([initiate('clerk_alice', {}, sameNonce), initiate('clerk_bob', {}, sameNonce)]);
+
+  assert.deepEqual(results.map((r) => r.status).sort(), [200, 409]);
+  const winner = results.find((r) => r.status === 200)!;
+  const loser = results.find((r) => r.status === 409)!;
+  assert.equal(loser.body.code, 'ESCROW_CONFLICT');
+  assert.equal(db.escrows.size, 1);
+  const row = db.escrows.get(winner.body.escrow_id)!;
+  assert.equal(row.txHash, winner.body.tx_hash);
+  assert.equal(db.users.get(row.userId)?.clerkUserId, results[0] === winner ? 'clerk_alice' : 'clerk_bob');
+});
+
+test('an escrow the chain worker stored first is claimed by the initiating user', async () => {
+  env.ESCROW_MODE = 'custodial';
+  // Mirror the worker's EscrowCreated upsert, which lands before the route writes.
+  chain.contracts().escrow.on('EscrowCreated', async (requestId: string, signer: string, verifier: string, amount: bigint) => {
+    const placeholder = db.addUser({ clerkUserId: signer.toLowerCase(), walletAddress: signer });
+    db.addEscrow({ id: requestId, requestId, userId: placeholder.id, verifierId, amount, status: 'in_progress' });
+  });
+
+  const out = await initiate('clerk_alice');
+
+  assert.equal(out.status, 200);
+  const row = db.escrows.get(out.body.escrow_id)!;
+  assert.equal(db.users.get(row.userId)?.clerkUserId, 'clerk_alice');
+  assert.equal(row.txHash, out.body.tx_hash);
+  assert.equal(row.status, 'in_progress', 'status set by the worker is kept');
+});
+
+test('custodial initiate is refused with 503 once the spend budget is used up', async () => {
+  env.ESCROW_MODE = 'custodial';
+  const spend = new SpendGuard({ ...limits, budget: FEE + limits.gasLimit * chain.feeData.maxFeePerGas });
+
+  const first = await initiate('clerk_alice', {}, { spend });
+  const second = await initiate('clerk_bob', {}, { spend });
+
+  assert.equal(first.status, 200);
+  assert.equal(second.status, 503);
+  assert.equal(second.body.code, 'SPEND_BUDGET_EXCEEDED');
+  assert.equal(chain.callsTo('createEscrow').length, 1);
+});
+
+test('custodial initiate is refused while the network fee is above the cap', async () => {
+  env.ESCROW_MODE = 'custodial';
+  chain.feeData = { ...chain.feeData, maxFeePerGas: limits.maxFeePerGas + 1n };
+
+  const out = await initiate('clerk_alice');
+
+  assert.equal(out.status, 503);
+  assert.equal(out.body.code, 'NETWORK_FEE_TOO_HIGH');
+  assert.equal(chain.calls.length, 0);
+});
+
+test('contracts are loaded with the request logger', async () => {
+  env.ESCROW_MODE = 'custodial';
+  const loggers: unknown[] = [];
+  const deps = { db, contracts: (log: unknown) => (loggers.push(log), chain.contracts()), spend: new SpendGuard(limits) } as unknown as EscrowDeps;
+  const { req } = fakeReq({ user: { id: 'clerk_erin' }, body: { verifier_id: verifierId } });
+  const { res, out } = fakeRes();
+
+  await initiateEscrow(deps)(req, res);
+
+  assert.equal(out.status, 200);
+  assert.deepEqual(loggers, [req.log]);
+});
@@ -0,0 +1,141 @@
+import type { Contracts } from '../../src/contracts';
+
+type ContractName = 'escrow' | 'registry' | 'marketplace';
+type Listener = (...args: any[]) => unknown;
+
+export type RecordedCall = { contract: ContractName; method: string; args: unknown[]; overrides: Record<string, any> };
+export type EmittedEvent = { contract: ContractName; name: string; args: unknown[] };
+
+const GWEI = 10n ** 9n;
+// Gas charged for every fake transaction, so tests can assert the exact fee taken from the signer.
+export const FAKE_GAS_USED = 100_000n;
+
+/**
+ * In-memory stand-in for the contracts returned by getContracts(). Transactions mine instantly with deterministic
+ * hashes (0x…01, 0x…02, …), value and gas move balances, calls are recorded, and events are delivered to `.on`
+ * listeners before `wait()` resolves. Contract-level require() checks are not modelled; use failNext() for reverts.
+ */
+export class FakeChain {
+  static readonly SIGNER = '0x00000000000000000000000000000000000051a7';
+  static readonly ADDRESSES = {
+    escrow: '0x000000000000000000000000000000000000e5c0',
+    registry: '0x0000000000000000000000000000000000000ec5',
+    marketplace: '0x000000000000000000000000000000000000a4e7',
+  };
+
+  readonly calls: RecordedCall[] = [];
+  readonly events: EmittedEvent[] = [];
+  readonly balances = new Map<string, bigint>();
+  /** Verification fee returned by marketplace.calculateVerificationFee, per verifier address. */
+  readonly verifierFees = new Map<string, bigint>();
+  feeData = { gasPrice: 10n * GWEI, maxFeePerGas: 20n * GWEI, maxPriorityFeePerGas: 1n * GWEI };
+
+  private txCount = 0;
+  private tokenCount = 0n;
+  private listeners = new Map<string, Listener[]>();
+  private failures = new Map<string, Error>();
+
+  constructor(private opts: { signer?: boolean; signerBalance?: bigint } = {}) {
+    this.balances.set(FakeChain.SIGNER, opts.signerBalance ?? 1000n * 10n ** 18n);
+  }
+
+  balanceOf(address: string): bigint {
+    return this.balances.get(address) ?? 0n;
+  }
+
+  /** Makes the next call to `method` revert with `error`. */
+  failNext(method: string, error = new Error(`execution reverted: ${method}`)) {
+    this.failures.set(method, error);
+  }
+
+  callsTo(method: string): RecordedCall[] {
+    return this.calls.filter((c) => c.method === method);
+  }
+
+  async emit(contract: ContractName, name: string, ...args: unknown[]) {
+    this.events.push({ contract, name, args });
+    await Promise.all((this.listeners.get(`${contract}:${name}`) ?? []).map((l) => l(...args)));
+  }
+
+  private on(source: ContractName | 'provider') {
+    return (name: string, listener: Listener) => {
+      const key = `${source}:${name}`;
+      this.listeners.set(key, [...(this.listeners.get(key) ?? []), listener]);
+    };
+  }
+
+  private transfer(from: string, to: string, amount: bigint) {
+    if (this.balanceOf(from) < amount) throw new Error('insufficient funds for gas * price + value');
+    this.balances.set(from, this.balanceOf(from) - amount);
+    this.balances.set(to, this.balanceOf(to) + amount);
+  }
+
+  private send(contract: ContractName, method: string, args: unknown[], overrides: Record<string, any>, mined: () => Promise<unknown[]>) {
+    const failure = this.failures.get(method);
+    if (failure) {
+      this.failures.delete(method);
+      return Promise.reject(failure);
+    }
+    this.calls.push({ contract, method, args, overrides });
+    const gasPrice: bigint = overrides.maxFeePerGas ?? overrides.gasPrice ?? this.feeData.gasPrice;
+    this.transfer(FakeChain.SIGNER, '0x0000000000000000000000000000000000000000', FAKE_GAS_USED * gasPrice);
+    if (overrides.value) this.transfer(FakeChain.SIGNER, FakeChain.ADDRESSES[contract], overrides.value);
+    const hash = '0x' + (++this.txCount).toString(16).padStart(64, '0');
+    return Promise.resolve({
+      hash,
+      wait: async () => {
+        const logs = await mined();
+        return { hash, status: 1, gasUsed: FAKE_GAS_USED, gasPrice, fee: FAKE_GAS_USED * gasPrice, logs };
+      },
+    });
+  }
+
+  contracts(): Contracts {
+    const signer = this.opts.signer === false ? undefined : { address: FakeChain.SIGNER, provider: {} };
+    const provider = {
+      estimateGas: async () => 150_000n,
+      getFeeData: async () => ({ ...this.feeData }),
+      getBlockNumber: async () => this.txCount,
+      on: this.on('provider'),
+    };
+    const escrow = {
+      runner: signer ?? provider,
+      on: this.on('escrow'),
+      createEscrow: (requestId: string, verifier: string, overrides: Record<string, any> = {}) =>
+        this.send('escrow', 'createEscrow', [requestId, verifier], overrides, async () => {
+          await this.emit('escrow', 'EscrowCreated', requestId, FakeChain.SIGNER, verifier, overrides.value ?? 0n);
+          return [];
+        }),
+    };
+    const registry = {
+      runner: signer ?? provider,
+      on: this.on('registry'),
+      issueCredential: (holder: string, did: string, vcHash: string, ...rest: unknown[]) => {
+        const last = rest[rest.length - 1];
+        const overrides = last && typeof last === 'object' && !Array.isArray(last) ? (last as Record<string, any>) : {};
+        return this.send('registry', 'issueCredential', [holder, did, vcHash, ...rest], overrides, async () => {
+          const tokenId = ++this.tokenCount;
+          await this.emit('registry', 'CredentialIssued', tokenId, vcHash, FakeChain.SIGNER, holder, did);
+          return [{ topics: ['CredentialIssued'], data: '0x' + tokenId.toString(16) }];
+        });
+      },
+    };
+    const marketplace = {
+      calculateVerificationFee: async (verifier: string) => this.verifierFees.get(verifier) ?? 10n ** 18n,
+    };
+    const iface = {
+      escrow: { encodeFunctionData: (fn: string, args: unknown[]) => `fake:${fn}(${args.join(',')})` },
+      registry: { parseLog: (log: { topics: string[]; data: string }) => ({ name: log.topics[0], args: [BigInt(log.data)] }) },
+      marketplace: {},
+    };
+    return {
+      provider,
+      signer,
+      addresses: { ...FakeChain.ADDRESSES },
+      escrow,
+      registry,
+      marketplace,
+      iface,
+    } as unknown as Contracts;
+  }
+}
@@ -0,0 +1,94 @@
+// In-memory stand-in for the Prisma models the escrow routes touch. Only the query shapes those routes use are
+// supported. Every call yields to the event loop first so concurrent requests interleave like they would against
+// Postgres, while each write is atomic like a single statement.
+
+type Row = Record<string, any>;
+
+export class UniqueConstraintError extends Error {
+  code = 'P2002';
+  constructor(public meta: { target: string[] }) {
+    super(`Unique constraint failed on the fields: (${meta.target.join(', ')})`);
+  }
+}
+
+const tick = () => new Promise<void>((resolve) => setImmediate(resolve));
+
+function matches(row: Row, where: Row, db: FakeDb): boolean {
+  return Object.entries(where).every(([key, want]) => {
+    if (key === 'user') return matches(db.users.get(row.userId) ?? {}, want, db);
+    return row[key] === want;
+  });
+}
+
+export class FakeDb {
+  readonly users = new Map<string, Row>();
+  readonly verifiers = new Map<string, Row>();
+  readonly escrows = new Map<string, Row>();
+  private seq = 0;
+
+  private nextId(prefix: string) {
+    return `${prefix}_${++this.seq}`;
+  }
+
+  addUser(data: Row): Row {
+    const row = { id: this.nextId('user'), did: null, walletAddress: null, createdAt: new Date(), ...data };
+    this.users.set(row.id, row);
+    return row;
+  }
+
+  addVerifier(data: Row): Row {
+    const row = { id: this.nextId('verifier'), name: 'Verifier', fee: 0n, currency: 'HBAR', status: 'active', createdAt: new Date(), ...data };
+    this.verifiers.set(row.id, row);
+    return row;
+  }
+
+  addEscrow(data: Row): Row {
+    if (this.escrows.has(data.id)) throw new UniqueConstraintError({ target: ['id'] });
+    const row = { status: 'submitted', currency: 'HBAR', autoReleaseAt: null, txHash: null, createdAt: new Date(), updatedAt: new Date(), ...data };
+    this.escrows.set(row.id, row);
+    return row;
+  }
+
+  readonly user = {
+    upsert: async ({ where, update, create }: { where: Row; update: Row; create: Row }) => {
+      await tick();
+      const existing = [...this.users.values()].find((u) => matches(u, where, this));
+      if (existing) return Object.assign(existing, update);
+      return this.addUser(create);
+    },
+    update: async ({ where, data }: { where: Row; data: Row }) => {
+      await tick();
+      const row = [...this.users.values()].find((u) => matches(u, where, this));
+      if (!row) throw new Error('Record to update not found.');
+      return Object.assign(row, data);
+    },
+  };
+
+  readonly verifier = {
+    findUnique: async ({ where }: { where: Row }) => {
+      await tick();
+      return [...this.verifiers.values()].find((v) => matches(v, where, this)) ?? null;
+    },
+    create: async ({ data }: { data: Row }) => {
+      await tick();
+      return this.addVerifier(data);
+    },
+  };
+
+  readonly escrow = {
+    findUnique: async ({ where }: { where: Row }) => {
+      await tick();
+      return this.escrows.get(where.id) ?? null;
+    },
+    create: async ({ data }: { data: Row }) => {
+      await tick();
+      return this.addEscrow(data);
+    },
+    updateMany: async ({ where, data }: { where: Row; data: Row }) => {
+      await tick();
+      const rows = [...this.escrows.values()].filter((e) => matches(e, where, this));
+      for (const row of rows) Object.assign(row, data, { updatedAt: new Date() });
+      return { count: rows.length };
+    },
+  };
+}
@@ -0,0 +1,30 @@
+import type { Request, Response } from 'express';
+
+// Just enough of Express's req/res for calling route handlers and middleware directly.
+
+export function fakeReq(init: { body?: unknown; params?: Record<string, string>; query?: Record<string, unknown>; user?: { id: string }; ip?: string; url?: string } = {}) {
+  const logs: { level: string; args: unknown[] }[] = [];
+  const log = Object.fromEntries(['debug', 'info', 'warn', 'error'].map((level) => [level, (...args: unknown[]) => logs.push({ level, args })]));
+  const req = {
+    body: init.body ?? {},
+    params: init.params ?? {},
+    query: init.query ?? {},
+    user: init.user,
+    ip: init.ip ?? '127.0.0.1',
+    originalUrl: init.url ?? '/',
+    accepts: (types: string[]) => types[0],
+    log,
+  };
+  return { req: req as unknown as Request, logs };
+}
+
+export function fakeRes() {
+  const out: { status: number; body?: any; headers: Record<string, string> } = { status: 200, headers: {} };
+  const res = {
+    status(code: number) { out.status = code; return res; },
+    type(_t: string) { return res; },
+    json(body: unknown) { out.body = body; return res; },
+    setHeader(name: string, value: string | number) { out.headers[name.toLowerCase()] = String(value); return res; },
+  };
+  return { res: res as unknown as Response, out };
+}
@@ -0,0 +1,56 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import { FieldSchema, parseFieldsParam, pickFields } from '../src/utils/fields';
+
+const schema: FieldSchema = {
+  id: true,
+  name: true,
+  onchain: { reputationScore: true, stakedAmount: true },
+  steps: { key: true, status: true },
+};
+
+const body = {
+  id: 'v1',
+  name: 'Alpha',
+  fee: '0',
+  onchain: { reputationScore: 90, stakedAmount: '100', isActive: true },
+  steps: [{ key: 'created', status: 'done' }, { key: 'settlement', status: 'pending' }],
+};
+
+test('no fields param returns the full body', () => {
+  const { fields, unknown } = parseFieldsParam(undefined, schema);
+  assert.equal(fields, null);
+  assert.deepEqual(unknown, []);
+  assert.deepEqual(pickFields(body, fields), body);
+});
+
+test('selects a subset including nested and array fields', () => {
+  const { fields, unknown } = parseFieldsParam('id, onchain.reputationScore,steps.key', schema);
+  assert.deepEqual(unknown, []);
+  assert.deepEqual(pickFields(body, fields), {
+    id: 'v1',
+    onchain: { reputationScore: 90 },
+    steps: [{ key: 'created' }, { key: 'settlement' }],
+  });
+});
+
+test('selecting a parent returns its whole subtree', () => {
+  const { fields } = parseFieldsParam('onchain.stakedAmount,onchain', schema);
+  assert.deepEqual(pickFields(body, fields), { onchain: body.onchain });
+});
+
+test('null nested objects are kept as null', () => {
+  const { fields } = parseFieldsParam('onchain.reputationScore', schema);
+  assert.deepEqual(pickFields({ ...body, onchain: null }, fields), { onchain: null });
+});
+
+test('rejects unknown and non-allowlisted fields', () => {
+  const { unknown } = parseFieldsParam('id,fee,onchain.isActive,name.first,__proto__', schema);
+  assert.deepEqual(unknown, ['fee', 'onchain.isActive', 'name.first', '__proto__']);
+});
+
+test('accepts a repeated query param as a list', () => {
+  const { fields, unknown } = parseFieldsParam(['id', 'name'], schema);
+  assert.deepEqual(fields, ['id', 'name']);
+  assert.deepEqual(unknown, []);
+});
@@ -0,0 +1,47 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import type { IncomingMessage, ServerResponse } from 'http';
+import { sampleSuccessfulRequests } from '../src/utils/logSampling';
+
+const req = {} as IncomingMessage;
+const res = (statusCode: number) => ({ statusCode }) as ServerResponse;
+
+function logged(skip: ReturnType<typeof sampleSuccessfulRequests>, statuses: number[]): number[] {
+  return statuses.filter((s) => !skip(req, res(s)));
+}
+
+test('under a burst, successful requests are sampled but errors all pass through', () => {
+  const burst: number[] = [];
+  for (let i = 0; i < 1000; i++) burst.push(i % 50 === 0 ? 500 : i % 25 === 0 ? 404 : i % 7 === 0 ? 304 : 200);
+  const errors = burst.filter((s) => s >= 400);
+  const successes = burst.length - errors.length;
+
+  const out = logged(sampleSuccessfulRequests(0.1), burst);
+  assert.deepEqual(out.filter((s) => s >= 400), errors);
+  assert.equal(out.filter((s) => s < 400).length, Math.floor(successes * 0.1));
+});
+
+test('a rate of 1 logs everything and 0 logs only errors', () => {
+  const statuses = [200, 201, 302, 400, 500];
+  assert.deepEqual(logged(sampleSuccessfulRequests(1), statuses), statuses);
+  assert.deepEqual(logged(sampleSuccessfulRequests(0), statuses), [400, 500]);
+});
+
+test('ACCESS_LOG_SAMPLE_RATE outside [0, 1] fails at boot', () => {
+  const envModule = require.resolve('../src/config/env');
+  const load = (rate: string) => {
+    process.env.ACCESS_LOG_SAMPLE_RATE = rate;
+    delete require.cache[envModule];
+    return require('../src/config/env').env.ACCESS_LOG_SAMPLE_RATE;
+  };
+  try {
+    assert.equal(load('0.25'), 0.25);
+    assert.equal(load('0'), 0);
+    for (const bad of ['-0.5', '1.5', 'NaN', 'half', '']) {
+      assert.throws(() => load(bad), /Invalid ACCESS_LOG_SAMPLE_RATE/, bad);
+    }
+  } finally {
+    delete process.env.ACCESS_LOG_SAMPLE_RATE;
+    delete require.cache[envModule];
+  }
+});
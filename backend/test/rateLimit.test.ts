@@ -0,0 +1,80 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import { MemoryRateLimitStore, rateLimit, RateLimitStore } from '../src/middleware/rateLimit';
+import { fakeReq, fakeRes } from './fakes/http';
+
+function clock(start = 0) {
+  const c = { t: start, now: () => c.t };
+  return c;
+}
+
+async function hit(mw: ReturnType<typeof rateLimit>, init: Parameters<typeof fakeReq>[0] = { user: { id: 'u1' } }) {
+  const { req, logs } = fakeReq(init);
+  const { res, out } = fakeRes();
+  let passed = false;
+  await mw(req, res, () => { passed = true; });
+  return { passed, out, logs };
+}
+
+test('allows a burst, then answers 429 with Retry-After and X-RateLimit-Remaining', async () => {
+  const c = clock();
+  const mw = rateLimit('t', { perMinute: 60, burst: 3, store: new MemoryRateLimitStore(), now: c.now });
+
+  const remaining: string[] = [];
+  for (let i = 0; i < 3; i++) {
+    const r = await hit(mw);
+    assert.equal(r.passed, true);
+    remaining.push(r.out.headers['x-ratelimit-remaining']);
+  }
+  assert.deepEqual(remaining, ['2', '1', '0']);
+
+  const blocked = await hit(mw);
+  assert.equal(blocked.passed, false);
+  assert.equal(blocked.out.status, 429);
+  assert.equal(blocked.out.body.code, 'RATE_LIMITED');
+  assert.equal(blocked.out.headers['retry-after'], '1');
+  assert.equal(blocked.out.headers['x-ratelimit-remaining'], '0');
+
+  c.t += 1000; // 60/min refills one token per second
+  assert.equal((await hit(mw)).passed, true);
+});
+
+test('buckets are per user, falling back to the client IP', async () => {
+  const mw = rateLimit('t', { perMinute: 1, store: new MemoryRateLimitStore(), now: () => 0 });
+
+  assert.equal((await hit(mw, { user: { id: 'alice' } })).passed, true);
+  assert.equal((await hit(mw, { user: { id: 'alice' } })).passed, false);
+  assert.equal((await hit(mw, { user: { id: 'bob' } })).passed, true);
+  assert.equal((await hit(mw, { ip: '10.0.0.1' })).passed, true);
+  assert.equal((await hit(mw, { ip: '10.0.0.1' })).passed, false);
+  assert.equal((await hit(mw, { ip: '10.0.0.2' })).passed, true);
+});
+
+test('limiters with different names keep separate budgets on a shared store', async () => {
+  const store = new MemoryRateLimitStore();
+  const strict = rateLimit('escrow', { perMinute: 1, store, now: () => 0 });
+  const loose = rateLimit('reads', { perMinute: 100, store, now: () => 0 });
+
+  assert.equal((await hit(strict)).passed, true);
+  assert.equal((await hit(strict)).passed, false);
+  assert.equal((await hit(loose)).passed, true);
+});
+
+test('fails open with a warning when the store is unreachable', async () => {
+  const broken: RateLimitStore = { take: async () => { throw new Error('ECONNREFUSED'); } };
+  const mw = rateLimit('t', { perMinute: 1, store: broken });
+
+  const r = await hit(mw);
+  assert.equal(r.passed, true);
+  assert.equal(r.logs[0]?.level, 'warn');
+});
+
+test('the memory store evicts the least recently used keys', async () => {
+  const store = new MemoryRateLimitStore(2);
+  const bucket = { capacity: 1, refillPerSec: 0.001 };
+  await store.take('a', bucket, 0);
+  await store.take('b', bucket, 0);
+  await store.take('c', bucket, 0); // evicts a
+  assert.equal((await store.take('a', bucket, 0)).allowed, true);
+  assert.equal((await store.take('c', bucket, 0)).allowed, false);
+});
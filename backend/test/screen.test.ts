@@ -0,0 +1,49 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import type { FileScanner, ScanResult } from '../src/scanning';
+import { screenFiles } from '../src/scanning/screen';
+
+const files = [
+  { path: '/staging/a.pdf', originalname: 'a.pdf' },
+  { path: '/staging/b.png', originalname: 'b.png' },
+  { path: '/staging/c.jpg', originalname: 'c.jpg' },
+];
+
+// Flags files whose path is listed in `infected` and throws for those in `broken`.
+function mockScanner(infected: string[] = [], broken: string[] = []): FileScanner & { scanned: string[] } {
+  const scanned: string[] = [];
+  return {
+    name: 'mock',
+    scanned,
+    async scan(filePath: string): Promise<ScanResult> {
+      scanned.push(filePath);
+      if (broken.includes(filePath)) throw new Error('scanner down');
+      return infected.includes(filePath) ? { clean: false, signature: 'Eicar-Test-Signature' } : { clean: true };
+    },
+  };
+}
+
+test('clean files pass', async () => {
+  const result = await screenFiles(files, mockScanner(), 'closed');
+  assert.deepEqual(result, { ok: true, unscanned: [] });
+});
+
+test('a flagged file rejects the upload and stops scanning', async () => {
+  const scanner = mockScanner(['/staging/b.png']);
+  const result = await screenFiles(files, scanner, 'closed');
+  assert.deepEqual(result, { ok: false, reason: 'infected', file: files[1], signature: 'Eicar-Test-Signature' });
+  assert.deepEqual(scanner.scanned, ['/staging/a.pdf', '/staging/b.png']);
+});
+
+test('scanner failure rejects the upload when failing closed', async () => {
+  const result = await screenFiles(files, mockScanner([], ['/staging/a.pdf']), 'closed');
+  assert.deepEqual(result, { ok: false, reason: 'unavailable', file: files[0], err: 'scanner down' });
+});
+
+test('scanner failure accepts the file when failing open, but still flags malware', async () => {
+  const ok = await screenFiles(files, mockScanner([], ['/staging/a.pdf']), 'open');
+  assert.deepEqual(ok, { ok: true, unscanned: [{ file: files[0], err: 'scanner down' }] });
+
+  const infected = await screenFiles(files, mockScanner(['/staging/c.jpg'], ['/staging/a.pdf']), 'open');
+  assert.deepEqual(infected, { ok: false, reason: 'infected', file: files[2], signature: 'Eicar-Test-Signature' });
+});
@@ -0,0 +1,50 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import { SpendGuard, SpendRefusedError } from '../src/contracts/spend';
+
+const GWEI = 10n ** 9n;
+const HBAR = 10n ** 18n;
+const limits = { maxFeePerGas: 100n * GWEI, gasLimit: 200_000n, budget: 10n * HBAR, periodMs: 60_000 };
+
+function feeSource(maxFeePerGas: bigint | null, gasPrice = 10n * GWEI) {
+  return { getFeeData: async () => ({ gasPrice, maxFeePerGas, maxPriorityFeePerGas: maxFeePerGas === null ? null : 2n * GWEI }) };
+}
+
+test('transactions carry the configured gas limit and a fee within the cap', async () => {
+  const guard = new SpendGuard(limits);
+
+  const { overrides } = await guard.authorize(feeSource(40n * GWEI), HBAR);
+  assert.deepEqual(overrides, { value: HBAR, gasLimit: 200_000n, maxFeePerGas: 40n * GWEI, maxPriorityFeePerGas: 2n * GWEI });
+
+  const legacy = await guard.authorize(feeSource(null, 30n * GWEI));
+  assert.deepEqual(legacy.overrides, { value: 0n, gasLimit: 200_000n, gasPrice: 30n * GWEI });
+});
+
+test('sends are refused while the network fee is above the cap', async () => {
+  const guard = new SpendGuard(limits);
+  await assert.rejects(guard.authorize(feeSource(101n * GWEI)), (e: unknown) => e instanceof SpendRefusedError && e.reason === 'fee_cap');
+  assert.equal(guard.remaining(), limits.budget, 'a refused send reserves nothing');
+});
+
+test('the budget guard blocks over-budget sends until the period rolls over', async () => {
+  let now = 0;
+  const guard = new SpendGuard(limits, () => now);
+  const fees = feeSource(50n * GWEI); // worst-case gas: 200k * 50 gwei = 0.01 HBAR
+
+  await guard.authorize(fees, 6n * HBAR);
+  await assert.rejects(guard.authorize(fees, 4n * HBAR), (e: unknown) => e instanceof SpendRefusedError && e.reason === 'budget');
+  await guard.authorize(fees, 3n * HBAR);
+
+  now = limits.periodMs;
+  await guard.authorize(fees, 9n * HBAR);
+  assert.equal(guard.remaining(), HBAR - 200_000n * 50n * GWEI);
+});
+
+test('settling a receipt returns the unused gas reservation', async () => {
+  const guard = new SpendGuard(limits);
+  const { settle } = await guard.authorize(feeSource(50n * GWEI), HBAR);
+  assert.equal(guard.remaining(), 9n * HBAR - 200_000n * 50n * GWEI);
+
+  settle({ fee: 21_000n * 50n * GWEI });
+  assert.equal(guard.remaining(), 9n * HBAR - 21_000n * 50n * GWEI);
+});
@@ -0,0 +1,71 @@
+import { test } from 'node:test';
+import assert from 'node:assert/strict';
+import { createHmac } from 'crypto';
+import { env } from '../src/config/env';
+import { attemptDelivery, publishEvent, retryDelayMs, signPayload } from '../src/webhooks';
+
+const webhook = { url: 'https://hooks.example.com/verza', secret: 'whsec_test' };
+const delivery = { id: 'dlv_1', event: 'escrow.released', payload: { id: 'evt_1', type: 'escrow.released', data: { escrowId: '0xabc' } }, attempts: 0 };
+const now = new Date('2025-06-01T12:00:00Z');
+
+function recorder(status: number | Error) {
+  const sent: { url: string; headers: Record<string, string>; body: string }[] = [];
+  const send = async (url: string, init: { headers: Record<string, string>; body: string }) => {
+    sent.push({ url, headers: init.headers, body: init.body });
+    if (status instanceof Error) throw status;
+    return { status };
+  };
+  return { send, sent };
+}
+
+test('deliveries are signed with an HMAC-SHA256 of the exact body', async () => {
+  const { send, sent } = recorder(204);
+
+  const update = await attemptDelivery(webhook, delivery, send, now);
+
+  assert.deepEqual(update, { status: 'delivered', attempts: 1, lastStatus: 204, lastError: null, deliveredAt: now });
+  const [req] = sent;
+  assert.equal(req.url, webhook.url);
+  assert.deepEqual(JSON.parse(req.body), delivery.payload);
+  assert.equal(req.headers['X-Verza-Signature'], 'sha256=' + createHmac('sha256', webhook.secret).update(req.body).digest('hex'));
+  assert.equal(req.headers['X-Verza-Signature'], signPayload(webhook.secret, req.body));
+  assert.equal(req.headers['X-Verza-Event'], 'escrow.released');
+  assert.equal(req.headers['X-Verza-Delivery'], 'dlv_1');
+});
+
+test('failed attempts are rescheduled with backoff', async () => {
+  const http = await attemptDelivery(webhook, delivery, recorder(503).send, now);
+  assert.deepEqual(http, { status: 'pending', attempts: 1, lastStatus: 503, lastError: 'HTTP 503', nextAttemptAt: new Date(now.getTime() + 30_000) });
+
+  const network = await attemptDelivery(webhook, { ...delivery, attempts: 2 }, recorder(new Error('ECONNREFUSED')).send, now);
+  assert.deepEqual(network, { status: 'pending', attempts: 3, lastStatus: null, lastError: 'ECONNREFUSED', nextAttemptAt: new Date(now.getTime() + 120_000) });
+});
+
+test('a delivery is marked failed after the last attempt', async () => {
+  const update = await attemptDelivery(webhook, { ...delivery, attempts: env.WEBHOOK_MAX_ATTEMPTS - 1 }, recorder(500).send, now);
+  assert.equal(update.status, 'failed');
+  assert.equal(update.attempts, env.WEBHOOK_MAX_ATTEMPTS);
+  assert.equal(update.nextAttemptAt, undefined);
+});
+
+test('retry delay doubles from 30s and is capped at an hour', () => {
+  assert.deepEqual([1, 2, 3, 4].map(retryDelayMs), [30_000, 60_000, 120_000, 240_000]);
+  assert.equal(retryDelayMs(20), 3_600_000);
+});
+
+test('publishing queues one delivery per subscribed webhook', async () => {
+  const queries: unknown[] = [];
+  const created: any[] = [];
+  const db = {
+    webhook: { findMany: async (q: unknown) => { queries.push(q); return [{ id: 'wh_1' }, { id: 'wh_2' }]; } },
+    webhookDelivery: { createMany: async ({ data }: { data: any[] }) => { created.push(...data); return { count: data.length }; } },
+  };
+
+  await publishEvent('user_1', 'escrow.refunded', { escrowId: '0xabc' }, db as any);
+
+  assert.deepEqual(queries, [{ where: { userId: 'user_1', events: { has: 'escrow.refunded' } }, select: { id: true } }]);
+  assert.deepEqual(created.map((d) => d.webhookId), ['wh_1', 'wh_2']);
+  assert.equal(created[0].payload, created[1].payload, 'every webhook gets the same event');
+  assert.equal(created[0].payload.type, 'escrow.refunded');
+  assert.deepEqual(created[0].payload.data, { escrowId: '0xabc' });
+});